@@ -0,0 +1,178 @@
+package cmd
+
+// The config file, which sets flags and is reloaded on SIGHUP.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+
+	"ohthehugemanatee/k8s-controller-demo/controller"
+)
+
+// Sets each flag named in the YAML file at path, unless it was already set on
+// the command line.
+func applyConfigFile(flags *pflag.FlagSet, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Error reading config file: %v", err)
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("Error parsing config file %s: %v", path, err)
+	}
+	for name, value := range values {
+		f := flags.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("Unknown setting %q in %s", name, path)
+		}
+		if f.Changed {
+			continue
+		}
+		// Items of list flags such as --watch may contain commas themselves.
+		if items, ok := value.([]interface{}); ok {
+			if slice, ok := f.Value.(pflag.SliceValue); ok {
+				values := make([]string, len(items))
+				for i, item := range items {
+					values[i] = fmt.Sprint(item)
+				}
+				if err := slice.Replace(values); err != nil {
+					return fmt.Errorf("Invalid %s in %s: %v", name, path, err)
+				}
+				continue
+			}
+		}
+		if err := flags.Set(name, flagValue(value)); err != nil {
+			return fmt.Errorf("Invalid %s in %s: %v", name, path, err)
+		}
+	}
+	return nil
+}
+
+// Formats a YAML value as the flag would be given: lists comma separated and
+// maps as comma separated key=value pairs.
+func flagValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	case map[interface{}]interface{}:
+		var items []string
+		for key, item := range v {
+			items = append(items, fmt.Sprintf("%v=%v", key, item))
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// Reloads the config file on every SIGHUP until the process exits.
+func reloadOnSignal(c *controller.Controller, opts runOptions, filters map[string]*controller.SeverityFilterHandler) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		log.WithField("file", opts.configFile).Info("Reloading config")
+		if err := reload(c, &opts, filters); err != nil {
+			log.WithError(err).Error("Error reloading config, keeping the current settings")
+		}
+	}
+}
+
+// Reads the command line and config file again and applies what can change
+// while running: the log level, sink severities and the controller's
+// reloadable settings. Other changes are logged and ignored.
+func reload(c *controller.Controller, opts *runOptions, filters map[string]*controller.SeverityFilterHandler) error {
+	var next runOptions
+	var config controller.Config
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	// Root command flags, e.g. --version, aren't bound here.
+	flags.ParseErrorsWhitelist.UnknownFlags = true
+	bindFlags(flags, &next, &config)
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	if err := applyConfigFile(flags, opts.configFile); err != nil {
+		return err
+	}
+
+	// Check everything before changing anything.
+	severities := map[string]string{}
+	for sink := range filters {
+		min, ok := next.minSeverity[sink]
+		if !ok {
+			min = "Normal"
+		}
+		if _, err := controller.NewSeverityFilterHandler(min, nil); err != nil {
+			return fmt.Errorf("Invalid min-severity for %s: %v", sink, err)
+		}
+		severities[sink] = min
+	}
+	config, err := completeConfig(next, config)
+	if err != nil {
+		return err
+	}
+	if err := c.Reload(config); err != nil {
+		return err
+	}
+	c.SetEffectiveConfig(effectiveConfig(flags, &next))
+	if next.logLevel != opts.logLevel {
+		if err := setLogLevel(next.logLevel); err != nil {
+			log.WithError(err).Error("Error changing log level")
+		} else {
+			opts.logLevel = next.logLevel
+		}
+	}
+	for sink, min := range severities {
+		filter := filters[sink]
+		if old := filter.Min(); old != min {
+			filter.SetMin(min)
+			log.WithFields(log.Fields{"sink": sink, "old": old, "new": min}).Info("Minimum severity changed")
+		}
+	}
+
+	next.logLevel, next.minSeverity = opts.logLevel, opts.minSeverity
+	if !reflect.DeepEqual(next, *opts) {
+		log.Warn("Sink, client and tracing settings can't be changed while running, ignored")
+	}
+	return nil
+}
+
+// Replaces secret values at /config.
+const redacted = "***"
+
+// Returns the value of every flag, as merged from the command line, config
+// file and environment. Flags bound to a field of opts tagged secret:"true"
+// are redacted unless empty.
+func effectiveConfig(flags *pflag.FlagSet, opts *runOptions) map[string]string {
+	// Flag values point at the variable they are bound to.
+	secrets := map[uintptr]bool{}
+	fields := reflect.ValueOf(opts).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		if fields.Type().Field(i).Tag.Get("secret") == "true" {
+			secrets[fields.Field(i).Addr().Pointer()] = true
+		}
+	}
+	values := map[string]string{}
+	flags.VisitAll(func(f *pflag.Flag) {
+		value := f.Value.String()
+		if value != "" && secrets[reflect.ValueOf(f.Value).Pointer()] {
+			value = redacted
+		}
+		values[f.Name] = value
+	})
+	return values
+}
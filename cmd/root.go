@@ -0,0 +1,27 @@
+package cmd
+
+// Command line interface of the controller.
+
+import (
+	"github.com/spf13/cobra"
+
+	"ohthehugemanatee/k8s-controller-demo/version"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "k8s-controller",
+	Short: "Kubernetes controller which alerts on resource changes",
+	// Runtime errors are not usage errors.
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.Version = version.Get().String()
+	rootCmd.SetVersionTemplate("{{.Name}} {{.Version}}\n")
+	rootCmd.AddCommand(newRunCommand())
+}
+
+// Execute runs the command named on the command line.
+func Execute() error {
+	return rootCmd.Execute()
+}
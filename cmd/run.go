@@ -0,0 +1,652 @@
+package cmd
+
+// The run command, which wires flags into a running controller.
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"ohthehugemanatee/k8s-controller-demo/controller"
+)
+
+// Settings of the run command which are not part of controller.Config.
+type runOptions struct {
+	configFile     string
+	enablePprof    bool
+	pprofAddr      string
+	kubeconfig     string
+	watchEvents    bool
+	watchSecrets   bool
+	watchConfigs   bool
+	dryRun         bool
+	logFormat      string
+	logLevel       string
+	otlpEndpoint   string
+	pagerDutyKey   string `secret:"true"`
+	teamsURL       string `secret:"true"`
+	slackURL       string `secret:"true"`
+	dashboardURL   string
+	webhookURL     string `secret:"true"`
+	webhookTLS     controller.TLSOptions
+	webhookTimeout time.Duration
+	ndjson         bool
+	recordEvents   bool
+	podName        string
+	podNamespace   string
+	kafkaBrokers   []string
+	kafkaTopic     string
+	natsURL        string `secret:"true"`
+	natsSubject    string
+	natsAckWait    time.Duration
+	template       string
+	templateOut    string
+	minSeverity    map[string]string
+	watches        []string
+	severityRules  map[string]string
+	smtpAddr       string
+	smtpUsername   string
+	smtpPassword   string `secret:"true"`
+	smtpStartTLS   bool
+	emailFrom      string
+	emailTo        string
+	batchSize      int
+	batchInterval  time.Duration
+	auditPath      string
+	auditMaxSize   int64
+	deadLetterURL  string `secret:"true"`
+	breakerLimit   int
+	breakerDelay   time.Duration
+}
+
+func newRunCommand() *cobra.Command {
+	var opts runOptions
+	var config controller.Config
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Watch the cluster and send alerts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.configFile != "" {
+				// Flags given on the command line win over the file.
+				if err := applyConfigFile(cmd.Flags(), opts.configFile); err != nil {
+					return err
+				}
+			}
+			// Read only now rather than as the flag default, which --help prints.
+			if opts.smtpPassword == "" {
+				opts.smtpPassword = os.Getenv("SMTP_PASSWORD")
+			}
+			return run(opts, config, effectiveConfig(cmd.Flags(), &opts))
+		},
+	}
+
+	bindFlags(cmd.Flags(), &opts, &config)
+	return cmd
+}
+
+// Binds the run flags to opts and config.
+func bindFlags(flags *pflag.FlagSet, opts *runOptions, config *controller.Config) {
+	flags.StringVar(&opts.configFile, "config", "", "YAML file of flag values, e.g. 'watched-types: [Warning]'. Reloaded on SIGHUP.")
+	flags.StringVar(&opts.kubeconfig, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to a kubeconfig file, empty to use the in-cluster config.")
+	flags.StringVar(&config.Namespace, "namespace", meta_v1.NamespaceAll, "Only watch this namespace, empty for all namespaces.")
+	flags.BoolVar(&opts.watchEvents, "watch-events", false, "Also alert on core Events, e.g. BackOff or NodeNotReady.")
+	flags.BoolVar(&opts.watchSecrets, "watch-secrets", false, "Also alert when keys of a Secret are added, removed or modified. Values are never reported.")
+	flags.BoolVar(&opts.watchConfigs, "watch-configmaps", false, "Also alert when keys of a ConfigMap are added, removed or modified.")
+	flags.StringArrayVar(&opts.watches, "watch", nil, "Scope a resource as Kind[/namespace][:selector], e.g. Pod/default:app=web,tier!=db. Repeat per resource.")
+	flags.StringToStringVar(&opts.severityRules, "severity-rules", nil, "Override event statuses per resource by reason or event type, e.g. Pod.delete=Warning,Deployment.Updated=Normal. Kind * applies to every resource.")
+	flags.StringSliceVar(&config.WatchedReasons, "watched-reasons", nil, "Only alert on core Events with these reasons, e.g. BackOff,Failed,NodeNotReady,Rebooted. Empty allows all.")
+	flags.StringSliceVar(&config.WatchedTypes, "watched-types", nil, "Only alert on core Events of these types, Normal or Warning. Empty allows all.")
+	flags.IntVar(&config.Workers, "workers", 1, "Number of workers processing events.")
+	flags.StringVar(&config.MetricsAddr, "metrics-addr", ":8080", "Listen address for /metrics, /events, /status, /readyz and /config, empty to disable.")
+	flags.BoolVar(&opts.enablePprof, "enable-pprof", false, "Serve /debug/pprof on --pprof-addr.")
+	flags.StringVar(&opts.pprofAddr, "pprof-addr", "localhost:6060", "Listen address for /debug/pprof, separate from the metrics address.")
+	flags.IntVar(&config.EventHistorySize, "event-history-size", controller.DefaultEventHistorySize, "Number of recent events served at /events.")
+	flags.StringVar(&opts.logFormat, "log-format", "text", "Log output format, text or json.")
+	flags.StringVar(&opts.logLevel, "log-level", envOrDefault("LOG_LEVEL", "info"), "Log level: trace, debug, info, warn or error. Defaults to $LOG_LEVEL.")
+	flags.StringVar(&opts.otlpEndpoint, "otlp-endpoint", "", "Export traces to this OTLP gRPC collector (host:port), empty to disable tracing.")
+
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "Log the events each sink would receive as JSON instead of sending them.")
+	flags.StringVar(&opts.pagerDutyKey, "pagerduty-routing-key", "", "Send events to PagerDuty using this integration routing key.")
+	flags.StringVar(&opts.teamsURL, "teams-webhook-url", "", "Send events to this Microsoft Teams incoming webhook.")
+	flags.StringVar(&opts.slackURL, "slack-webhook-url", "", "Send events to this Slack incoming webhook.")
+	flags.StringVar(&opts.dashboardURL, "dashboard-base-url", "", "Link Slack messages to {base}/{namespace}/{kind}/{name}.")
+	flags.StringVar(&opts.webhookURL, "webhook-url", "", "POST events as JSON to this URL.")
+	flags.StringVar(&opts.webhookTLS.CAFile, "webhook-ca-file", "", "PEM bundle of extra CAs trusted for the webhook.")
+	flags.StringVar(&opts.webhookTLS.CertFile, "webhook-cert-file", "", "Client certificate for mutual TLS with the webhook.")
+	flags.StringVar(&opts.webhookTLS.KeyFile, "webhook-key-file", "", "Client key for mutual TLS with the webhook.")
+	flags.BoolVar(&opts.webhookTLS.InsecureSkipVerify, "webhook-insecure-skip-verify", false, "Don't verify the webhook's certificate. Insecure, for testing only.")
+	flags.DurationVar(&opts.webhookTimeout, "webhook-timeout", controller.DefaultHTTPTimeout, "Fail a webhook request, so it is retried, if it takes longer than this.")
+	flags.StringSliceVar(&opts.kafkaBrokers, "kafka-brokers", nil, "Produce events as JSON to Kafka through these brokers (host:port).")
+	flags.StringVar(&opts.kafkaTopic, "kafka-topic", "k8s-events", "Kafka topic for events.")
+	flags.StringVar(&opts.natsURL, "nats-url", "", "Publish events as JSON to the NATS server at this URL, e.g. nats://localhost:4222.")
+	flags.StringVar(&opts.natsSubject, "nats-subject", "k8s.events", "NATS subject for events.")
+	flags.DurationVar(&opts.natsAckWait, "nats-jetstream-ack-wait", 0, "Publish through JetStream and retry events not acked within this time, 0 for plain NATS.")
+	flags.BoolVar(&opts.recordEvents, "record-events", false, "Create a Kubernetes Event on the involved object for every event.")
+	flags.StringVar(&opts.podName, "pod-name", os.Getenv("POD_NAME"), "Name of the controller's own Pod, which --record-events records heartbeats on. Defaults to $POD_NAME, e.g. set from the downward API.")
+	flags.StringVar(&opts.podNamespace, "pod-namespace", os.Getenv("POD_NAMESPACE"), "Namespace of the controller's own Pod. Defaults to $POD_NAMESPACE.")
+	flags.BoolVar(&opts.ndjson, "ndjson", false, "Write events to stdout as newline delimited JSON.")
+	flags.StringVar(&opts.template, "template", "", "Render events with this Go text/template, e.g. '{{.Status}} {{.Kind}} {{.Namespace}}/{{.Name}}'.")
+	flags.StringVar(&opts.templateOut, "template-output", "-", "Where rendered events go: - for stdout, an http(s) URL to POST to, or a file path to append to.")
+	flags.StringVar(&opts.smtpAddr, "smtp-addr", "", "Email events through this SMTP server (host:port).")
+	flags.StringVar(&opts.smtpUsername, "smtp-username", os.Getenv("SMTP_USERNAME"), "SMTP user name. Defaults to $SMTP_USERNAME.")
+	flags.StringVar(&opts.smtpPassword, "smtp-password", "", "SMTP password. Defaults to $SMTP_PASSWORD, which keeps it off the command line.")
+	flags.BoolVar(&opts.smtpStartTLS, "smtp-starttls", false, "Upgrade the SMTP connection with STARTTLS.")
+	flags.StringVar(&opts.emailFrom, "email-from", "", "Sender address for event emails.")
+	flags.StringVar(&opts.emailTo, "email-to", "", "Comma separated recipient addresses for event emails.")
+	flags.StringToStringVar(&opts.minSeverity, "min-severity", nil, "Minimum severity (Normal, Warning or Danger) per sink, e.g. webhook=Danger,template=Normal. Sinks are pagerduty, teams, slack, webhook, kafka, nats, events, ndjson, template and email.")
+	flags.IntVar(&opts.breakerLimit, "circuit-breaker-threshold", 0, "Stop calling a sink for --circuit-breaker-cooldown after this many consecutive failures, 0 to disable. Events fail fast meanwhile and are retried.")
+	flags.DurationVar(&opts.breakerDelay, "circuit-breaker-cooldown", 30*time.Second, "How long an open circuit breaker waits before probing its sink again.")
+	flags.IntVar(&opts.batchSize, "batch-size", 0, "Flush events to the handler in batches of this size, 0 to disable batching. The webhook receives a JSON array and email one digest per batch.")
+	flags.DurationVar(&opts.batchInterval, "batch-interval", 10*time.Second, "Maximum time an event waits in a partial batch.")
+	flags.StringVar(&opts.auditPath, "audit-file", "", "Append every event as a JSON line to this file, empty to disable.")
+	flags.Int64Var(&opts.auditMaxSize, "audit-max-bytes", 100<<20, "Rotate the audit file once it reaches this size, 0 to never rotate.")
+
+	flags.IntVar(&config.AlertsPerMinute, "alerts-per-minute", 0, "Maximum alerts per minute for any single object, 0 for no limit.")
+	flags.StringVar(&config.IgnoreAnnotation, "ignore-annotation", "k8s-controller/ignore", "Objects with this annotation set to true never alert.")
+	flags.StringSliceVar(&config.IgnoreManagedBy, "ignore-managed-by", nil, "Never alert on objects whose fields were written by one of these managers, or owned by an object of one of these kinds.")
+	flags.DurationVar(&config.MinCreateAge, "min-create-age", 0, "Only alert on creation of objects at least this old.")
+	flags.DurationVar(&config.MaxCreateAge, "max-create-age", 5*time.Minute, "Only alert on creation of objects at most this old, 0 for no limit.")
+	flags.BoolVar(&config.Backfill, "backfill", false, "Emit create events for all existing objects on startup.")
+	flags.StringSliceVar(&config.MetricsNamespaces, "metrics-namespaces", nil, "Namespaces labelled individually in events_total, others are counted as \"other\". Empty labels every namespace.")
+	flags.StringSliceVar(&config.UpdateWatchFields, "update-watch-fields", nil, "Only alert on updates changing one of these field paths, e.g. spec.template.spec.containers[*].image. Empty alerts on any spec change.")
+	flags.DurationVar(&config.ResyncPeriod, "resync-period", 0, "How often informers re-deliver all cached objects, 0 to disable. The objects are unchanged, so they don't alert.")
+	flags.DurationVar(&config.RetryBaseDelay, "retry-base-delay", controller.DefaultRetryBaseDelay, "Initial backoff for a failed event.")
+	flags.DurationVar(&config.RetryMaxDelay, "retry-max-delay", controller.DefaultRetryMaxDelay, "Maximum backoff for a failed event.")
+	flags.Float64Var(&config.QueueQPS, "queue-qps", controller.DefaultQueueQPS, "Overall rate at which retried events are requeued.")
+	flags.IntVar(&config.QueueBurst, "queue-burst", controller.DefaultQueueBurst, "Burst size for requeued events.")
+	flags.Float64Var(&config.RetryJitter, "retry-jitter", 0, "Add a random delay of up to this fraction of each retry backoff.")
+	flags.DurationVar(&config.MaxItemAge, "max-item-age", 0, "Give up on an event this long after it was first queued, 0 to only limit retries.")
+	flags.StringVar((*string)(&config.GiveUpPolicy), "give-up-policy", string(controller.GiveUpDeadLetter), "What to do with an event out of retries: forget, dead-letter, or fatal to exit.")
+	flags.StringVar(&opts.deadLetterURL, "dead-letter-url", "", "POST events the controller gives up on as JSON to this URL. Without one they are only logged.")
+	flags.IntVar(&config.RestartThreshold, "restart-threshold", 0, "Only alert on a crash looping pod once it restarted more than this many times.")
+	flags.DurationVar(&config.MinObjectAge, "min-object-age", 0, "Only alert on a crash looping pod at least this old.")
+	flags.DurationVar(&config.ShutdownTimeout, "shutdown-timeout", 25*time.Second, "How long to process queued events on shutdown before dead-lettering them, 0 to wait for all.")
+	flags.IntVar(&config.OwnerCacheSize, "owner-cache-size", controller.DefaultOwnerCacheSize, "Number of resolved Pod owners to cache.")
+	flags.DurationVar(&config.OwnerCacheTTL, "owner-cache-ttl", controller.DefaultOwnerCacheTTL, "How long a resolved Pod owner is cached.")
+	flags.DurationVar(&config.HeartbeatInterval, "heartbeat-interval", 0, "Send a Heartbeat event through the handlers this often, 0 to disable.")
+	flags.DurationVar(&config.RolloutWindow, "rollout-window", 0, "Replace a pod delete followed within this window by a create under the same owner with one RolloutProgress event, 0 to disable.")
+	flags.IntVar(&config.MaxWatchErrors, "max-watch-errors", 0, "Exit after this many consecutive watch failures for a resource, so an orchestrator restarts the process. The informer backs off between retries, so use a high value, e.g. 100. 0 retries forever.")
+}
+
+// Builds the controller from the command line and runs it until it fails.
+// effective is the redacted flag values served at /config.
+func run(opts runOptions, config controller.Config, effective map[string]string) error {
+	if err := setLogFormat(opts.logFormat); err != nil {
+		return err
+	}
+	if err := setLogLevel(opts.logLevel); err != nil {
+		return err
+	}
+	config, err := completeConfig(opts, config)
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	shutdownTracing, err := controller.SetupTracing(context.Background(), opts.otlpEndpoint)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+
+	kubeClient, err := newKubeClient(opts.kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	// Fail fast if RBAC doesn't allow watching.
+	if err := controller.CheckAccess(context.Background(), kubeClient, config.WatchSpec("Deployment").Namespace, "apps", "deployments"); err != nil {
+		return err
+	}
+	if err := controller.CheckAccess(context.Background(), kubeClient, config.WatchSpec("Pod").Namespace, "", "pods"); err != nil {
+		return err
+	}
+	if opts.watchEvents {
+		if err := controller.CheckAccess(context.Background(), kubeClient, config.WatchSpec("Event").Namespace, "", "events"); err != nil {
+			return err
+		}
+	}
+	if opts.watchSecrets {
+		if err := controller.CheckAccess(context.Background(), kubeClient, config.WatchSpec("Secret").Namespace, "", "secrets"); err != nil {
+			return err
+		}
+	}
+	if opts.watchConfigs {
+		if err := controller.CheckAccess(context.Background(), kubeClient, config.WatchSpec("ConfigMap").Namespace, "", "configmaps"); err != nil {
+			return err
+		}
+	}
+
+	eventHandler, filters, err := newHandler(opts, kubeClient)
+	if err != nil {
+		return err
+	}
+	c := controller.NewController(kubeClient, eventHandler, config)
+	if opts.auditPath != "" {
+		sink, err := controller.NewAuditSink(opts.auditPath, opts.auditMaxSize)
+		if err != nil {
+			return err
+		}
+		c.SetAuditSink(sink)
+	}
+	deadLetter, err := newDeadLetterHandler(opts, config.GiveUpPolicy)
+	if err != nil {
+		return err
+	}
+	if deadLetter != nil {
+		c.SetDeadLetterHandler(deadLetter)
+	}
+	c.SetEffectiveConfig(effective)
+	if opts.configFile != "" {
+		go reloadOnSignal(c, opts, filters)
+	}
+
+	// Instantiate the informers.
+	deploymentSpec := config.WatchSpec("Deployment")
+	deploymentInformer := controller.NewInformer(
+		c.WatchErrorHandler("Deployment", &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return kubeClient.AppsV1().Deployments(deploymentSpec.Namespace).List(context.TODO(), scoped(deploymentSpec, options))
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return kubeClient.AppsV1().Deployments(deploymentSpec.Namespace).Watch(context.TODO(), scoped(deploymentSpec, options))
+			},
+		}),
+		&apps_v1.Deployment{},
+		config,
+	)
+	podSpec := config.WatchSpec("Pod")
+	podInformer := controller.NewInformer(
+		c.WatchErrorHandler("Pod", &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return kubeClient.CoreV1().Pods(podSpec.Namespace).List(context.TODO(), scoped(podSpec, options))
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return kubeClient.CoreV1().Pods(podSpec.Namespace).Watch(context.TODO(), scoped(podSpec, options))
+			},
+		}),
+		&api_v1.Pod{},
+		config,
+	)
+	c.AddInformer("Deployment", deploymentInformer)
+	c.AddInformer("Pod", podInformer)
+	if opts.watchEvents {
+		eventSpec := config.WatchSpec("Event")
+		eventInformer := controller.NewInformer(
+			c.WatchErrorHandler("Event", &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().Events(eventSpec.Namespace).List(context.TODO(), scoped(eventSpec, options))
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().Events(eventSpec.Namespace).Watch(context.TODO(), scoped(eventSpec, options))
+				},
+			}),
+			&api_v1.Event{},
+			config,
+		)
+		c.AddInformer("Event", eventInformer)
+	}
+	if opts.watchSecrets {
+		secretSpec := config.WatchSpec("Secret")
+		secretInformer := controller.NewInformer(
+			c.WatchErrorHandler("Secret", &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().Secrets(secretSpec.Namespace).List(context.TODO(), scoped(secretSpec, options))
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().Secrets(secretSpec.Namespace).Watch(context.TODO(), scoped(secretSpec, options))
+				},
+			}),
+			&api_v1.Secret{},
+			config,
+		)
+		c.AddInformer("Secret", secretInformer)
+	}
+	if opts.watchConfigs {
+		configMapSpec := config.WatchSpec("ConfigMap")
+		configMapInformer := controller.NewInformer(
+			c.WatchErrorHandler("ConfigMap", &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().ConfigMaps(configMapSpec.Namespace).List(context.TODO(), scoped(configMapSpec, options))
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().ConfigMaps(configMapSpec.Namespace).Watch(context.TODO(), scoped(configMapSpec, options))
+				},
+			}),
+			&api_v1.ConfigMap{},
+			config,
+		)
+		c.AddInformer("ConfigMap", configMapInformer)
+	}
+
+	return c.Run(stopOnSignal())
+}
+
+// Returns a channel closed on SIGTERM or SIGINT, so the controller drains its
+// queue before the process exits. A second signal exits straight away.
+func stopOnSignal() <-chan struct{} {
+	stopCh := make(chan struct{})
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		log.WithField("signal", <-signals).Info("Shutting down")
+		close(stopCh)
+		log.WithField("signal", <-signals).Warn("Exiting without draining")
+		os.Exit(1)
+	}()
+	return stopCh
+}
+
+// Builds the configured handler chain. Returns nil if no sink is configured.
+// The severity filter of each sink is returned by sink name, so it can be
+// changed on reload.
+func newHandler(opts runOptions, kubeClient kubernetes.Interface) (controller.Handler, map[string]*controller.SeverityFilterHandler, error) {
+	var handlers []controller.Handler
+	filters := map[string]*controller.SeverityFilterHandler{}
+	// Adds a sink, filtered by its minimum severity. Without one every event
+	// passes. With a circuit breaker threshold each sink gets its own breaker.
+	// A dry run replaces the sink itself, so it logs what the sink would get.
+	add := func(sink string, h controller.Handler) error {
+		if opts.dryRun {
+			h = controller.NewDryRunHandler(sink, h)
+		}
+		if opts.breakerLimit > 0 {
+			h = controller.NewCircuitBreakerHandler(h, opts.breakerLimit, opts.breakerDelay)
+		}
+		min, ok := opts.minSeverity[sink]
+		if !ok {
+			min = "Normal"
+		}
+		filtered, err := controller.NewSeverityFilterHandler(min, h)
+		if err != nil {
+			return fmt.Errorf("Invalid min-severity for %s: %v", sink, err)
+		}
+		filters[sink] = filtered
+		handlers = append(handlers, filtered)
+		return nil
+	}
+	if opts.pagerDutyKey != "" {
+		if err := add("pagerduty", &controller.PagerDutyHandler{RoutingKey: opts.pagerDutyKey}); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.teamsURL != "" {
+		if err := add("teams", &controller.TeamsHandler{WebhookURL: opts.teamsURL}); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.slackURL != "" {
+		if err := add("slack", &controller.SlackHandler{WebhookURL: opts.slackURL, DashboardBaseURL: opts.dashboardURL}); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.webhookURL != "" {
+		webhook, err := controller.NewWebhookHandler(opts.webhookURL, opts.webhookTLS, opts.webhookTimeout)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := add("webhook", webhook); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(opts.kafkaBrokers) > 0 {
+		if err := add("kafka", controller.NewKafkaHandler(opts.kafkaBrokers, opts.kafkaTopic)); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.natsURL != "" {
+		natsHandler, err := controller.NewNATSHandler(opts.natsURL, opts.natsSubject, opts.natsAckWait)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := add("nats", natsHandler); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.recordEvents {
+		recorder := controller.NewK8sEventRecorderHandler(kubeClient)
+		if opts.podName != "" && opts.podNamespace != "" {
+			recorder.SetControllerPod(opts.podNamespace, opts.podName)
+		}
+		if err := add("events", recorder); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.ndjson {
+		if err := add("ndjson", controller.NewNDJSONHandler(os.Stdout)); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.template != "" {
+		output, err := newTemplateOutput(opts.templateOut)
+		if err != nil {
+			return nil, nil, err
+		}
+		tmpl, err := controller.NewTemplateHandler(opts.template, output)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := add("template", tmpl); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.smtpAddr != "" {
+		to, err := emailRecipients(opts.emailTo)
+		if err != nil {
+			return nil, nil, err
+		}
+		email := &controller.EmailHandler{
+			SMTPAddr: opts.smtpAddr,
+			From:     opts.emailFrom,
+			To:       to,
+			StartTLS: opts.smtpStartTLS,
+		}
+		if opts.smtpUsername != "" {
+			host, _, _ := net.SplitHostPort(opts.smtpAddr)
+			email.Auth = smtp.PlainAuth("", opts.smtpUsername, opts.smtpPassword, host)
+		}
+		if err := add("email", email); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var eventHandler controller.Handler
+	if len(handlers) == 1 {
+		eventHandler = handlers[0]
+	} else if len(handlers) > 1 {
+		eventHandler = controller.NewMultiHandler(handlers...)
+	}
+	if opts.batchSize > 0 && eventHandler != nil {
+		batch, err := controller.NewBatchHandler(eventHandler, opts.batchSize, opts.batchInterval)
+		if err != nil {
+			return nil, nil, err
+		}
+		eventHandler = batch
+	}
+	return eventHandler, filters, nil
+}
+
+// Fills in the parts of config derived from run options.
+func completeConfig(opts runOptions, config controller.Config) (controller.Config, error) {
+	specs, err := watchSpecs(opts)
+	if err != nil {
+		return config, err
+	}
+	config.Watches = specs
+	rules, err := severityRules(opts)
+	if err != nil {
+		return config, err
+	}
+	config.SeverityRules = rules
+	if opts.enablePprof {
+		config.PprofAddr = opts.pprofAddr
+	}
+	return config, nil
+}
+
+// Builds the watch specs from --watch.
+func watchSpecs(opts runOptions) ([]controller.WatchSpec, error) {
+	var specs []controller.WatchSpec
+	for _, watch := range opts.watches {
+		scope, selector := watch, ""
+		if i := strings.Index(watch, ":"); i >= 0 {
+			scope, selector = watch[:i], watch[i+1:]
+		}
+		resource, namespace := scope, ""
+		if i := strings.Index(scope, "/"); i >= 0 {
+			resource, namespace = scope[:i], scope[i+1:]
+		}
+		if resource == "" {
+			return nil, fmt.Errorf("Invalid watch %q, expected Kind[/namespace][:selector]", watch)
+		}
+		specs = append(specs, controller.WatchSpec{Resource: resource, Namespace: namespace, LabelSelector: selector})
+	}
+	return specs, nil
+}
+
+// Builds the severity rules by resource type from --severity-rules.
+func severityRules(opts runOptions) (map[string]map[string]string, error) {
+	if len(opts.severityRules) == 0 {
+		return nil, nil
+	}
+	rules := map[string]map[string]string{}
+	for rule, status := range opts.severityRules {
+		i := strings.Index(rule, ".")
+		if i <= 0 || i == len(rule)-1 {
+			return nil, fmt.Errorf("Invalid severity rule %q, expected Kind.reason", rule)
+		}
+		resource := rule[:i]
+		if rules[resource] == nil {
+			rules[resource] = map[string]string{}
+		}
+		rules[resource][rule[i+1:]] = status
+	}
+	return rules, nil
+}
+
+// Applies the label selector of a watch spec to list and watch options.
+func scoped(spec controller.WatchSpec, options meta_v1.ListOptions) meta_v1.ListOptions {
+	if spec.LabelSelector != "" {
+		options.LabelSelector = spec.LabelSelector
+	}
+	return options
+}
+
+// Parses the comma separated --email-to addresses, requiring at least one.
+func emailRecipients(list string) ([]string, error) {
+	var to []string
+	for _, address := range strings.Split(list, ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(address); err != nil {
+			return nil, fmt.Errorf("Invalid email-to address %q: %v", address, err)
+		}
+		to = append(to, address)
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("--email-to is required with --smtp-addr")
+	}
+	return to, nil
+}
+
+// Builds the handler for events the controller gives up on, or nil if they
+// are only logged.
+func newDeadLetterHandler(opts runOptions, policy controller.GiveUpPolicy) (controller.Handler, error) {
+	if opts.deadLetterURL == "" {
+		if policy != controller.GiveUpForget {
+			log.WithField("policy", policy).Warn("No --dead-letter-url, events the controller gives up on are only logged")
+		}
+		return nil, nil
+	}
+	webhook, err := controller.NewWebhookHandler(opts.deadLetterURL, controller.TLSOptions{}, controller.DefaultHTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+	var deadLetter controller.Handler = webhook
+	if opts.dryRun {
+		deadLetter = controller.NewDryRunHandler("dead-letter", deadLetter)
+	}
+	return deadLetter, nil
+}
+
+// Picks stdout, a webhook or a file for rendered templates.
+func newTemplateOutput(target string) (func(string) error, error) {
+	switch {
+	case target == "-":
+		return controller.WriterOutput(os.Stdout), nil
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		return controller.WebhookOutput(nil, target), nil
+	default:
+		return controller.FileOutput(target)
+	}
+}
+
+// Connects using kubeconfig, or the in-cluster service account if it is empty.
+func newKubeClient(kubeconfig string) (kubernetes.Interface, error) {
+	var restConfig *rest.Config
+	var err error
+	if kubeconfig == "" {
+		restConfig, err = rest.InClusterConfig()
+	} else {
+		restConfig, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error loading Kubernetes client config: %v", err)
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// Sets the logrus formatter by name.
+func setLogFormat(format string) error {
+	switch format {
+	case "text":
+		log.SetFormatter(&log.TextFormatter{})
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("Unknown log format %q, expected text or json", format)
+	}
+	return nil
+}
+
+// Sets the logrus level by name.
+func setLogLevel(level string) error {
+	lvl, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(lvl)
+	return nil
+}
+
+// Returns the environment variable key, or def if it is unset.
+func envOrDefault(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/pflag"
+
+	"ohthehugemanatee/k8s-controller-demo/controller"
+)
+
+func TestEmailRecipients(t *testing.T) {
+	to, err := emailRecipients("ops@example.com, oncall@example.com,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ops@example.com", "oncall@example.com"}; !reflect.DeepEqual(to, want) {
+		t.Errorf("Got %q, want %q", to, want)
+	}
+	for _, list := range []string{"", " , ", "not an address"} {
+		if _, err := emailRecipients(list); err == nil {
+			t.Errorf("Got no error for --email-to %q", list)
+		}
+	}
+}
+
+func TestJSONLogFormat(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+	defer setLogFormat("text")
+
+	if err := setLogFormat("json"); err != nil {
+		t.Fatal(err)
+	}
+	log.WithFields(log.Fields{"namespace": "default", "kind": "Pod", "name": "web-1"}).Info("Dispatching event")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Got %q, want a JSON line: %v", out.String(), err)
+	}
+	for field, want := range map[string]string{"level": "info", "msg": "Dispatching event", "namespace": "default", "kind": "Pod", "name": "web-1"} {
+		if entry[field] != want {
+			t.Errorf("Got %s %v, want %s", field, entry[field], want)
+		}
+	}
+	if _, ok := entry["time"]; !ok {
+		t.Error("Got no time field")
+	}
+	if err := setLogFormat("xml"); err == nil {
+		t.Error("Got no error for log format xml")
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	defer log.SetLevel(log.GetLevel())
+
+	for level, want := range map[string]log.Level{"trace": log.TraceLevel, "debug": log.DebugLevel, "warn": log.WarnLevel, "error": log.ErrorLevel} {
+		if err := setLogLevel(level); err != nil {
+			t.Fatal(err)
+		}
+		if got := log.GetLevel(); got != want {
+			t.Errorf("Level %s set %s, want %s", level, got, want)
+		}
+	}
+	if err := setLogLevel("loud"); err == nil {
+		t.Error("Got no error for log level loud")
+	}
+}
+
+func TestLogLevelDefaultsToEnv(t *testing.T) {
+	os.Setenv("LOG_LEVEL", "debug")
+	defer os.Unsetenv("LOG_LEVEL")
+
+	flag := newRunCommand().Flags().Lookup("log-level")
+	if flag == nil || flag.DefValue != "debug" {
+		t.Errorf("Got --log-level %+v, want it to default to $LOG_LEVEL", flag)
+	}
+}
+
+func TestRunHelpListsFlags(t *testing.T) {
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"run", "--help"})
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetArgs(nil)
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"--kubeconfig", "--namespace", "--workers int", "(default 1)", "--log-level", "--metrics-addr", "(default \":8080\")"} {
+		if !bytes.Contains(out.Bytes(), []byte(want)) {
+			t.Errorf("Help doesn't mention %s:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestHelpHidesSMTPPassword(t *testing.T) {
+	os.Setenv("SMTP_PASSWORD", "hunter2")
+	defer os.Unsetenv("SMTP_PASSWORD")
+	var out bytes.Buffer
+	cmd := newRunCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--help"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out.Bytes(), []byte("hunter2")) {
+		t.Errorf("Help shows $SMTP_PASSWORD:\n%s", out.String())
+	}
+}
+
+func TestDryRunRespectsSinkFilters(t *testing.T) {
+	logs := test.NewGlobal()
+	h, _, err := newHandler(runOptions{dryRun: true, ndjson: true, minSeverity: map[string]string{"ndjson": "Danger"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, status := range []string{"Normal", "Danger"} {
+		if err := h.Handle(controller.Event{Kind: "Pod", Name: "web-1", Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries := logs.AllEntries()
+	if len(entries) != 1 || entries[0].Data["sink"] != "ndjson" || !strings.Contains(entries[0].Message, `"Status":"Danger"`) {
+		t.Fatalf("Got %d log entries %+v, want only the Danger event logged for ndjson", len(entries), entries)
+	}
+}
+
+func TestPprofOnlyWhenEnabled(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		config, err := completeConfig(runOptions{enablePprof: enabled, pprofAddr: "localhost:6060"}, controller.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if served := config.PprofAddr != ""; served != enabled {
+			t.Errorf("--enable-pprof=%t served pprof %t", enabled, served)
+		}
+	}
+}
+
+func TestEffectiveConfigRedactsSecrets(t *testing.T) {
+	var opts runOptions
+	var config controller.Config
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	bindFlags(flags, &opts, &config)
+	if err := flags.Parse([]string{"--slack-webhook-url", "https://hooks.slack.com/services/T0/B0/secret", "--namespace", "prod"}); err != nil {
+		t.Fatal(err)
+	}
+
+	values := effectiveConfig(flags, &opts)
+	if got := values["slack-webhook-url"]; got != redacted {
+		t.Errorf("Got --slack-webhook-url %q, want %s", got, redacted)
+	}
+	if got := values["namespace"]; got != "prod" {
+		t.Errorf("Got --namespace %q, want prod", got)
+	}
+	if got := values["webhook-url"]; got != "" {
+		t.Errorf("Got unset --webhook-url %q, want it empty", got)
+	}
+}
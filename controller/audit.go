@@ -0,0 +1,90 @@
+package controller
+
+// Append-only audit trail of every event the controller produced.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditSink appends events as JSON lines to a file, rotating it by size.
+type AuditSink struct {
+	path    string
+	maxSize int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewAuditSink opens path for appending. Once the file would exceed maxSize bytes
+// it is renamed with a timestamp suffix and a new file started; 0 never rotates.
+func NewAuditSink(path string, maxSize int64) (*AuditSink, error) {
+	a := &AuditSink{path: path, maxSize: maxSize}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *AuditSink) open() error {
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.file = f
+	a.size = info.Size()
+	return nil
+}
+
+// Record appends e to the audit file.
+func (a *AuditSink) Record(e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.maxSize > 0 && a.size > 0 && a.size+int64(len(line)) > a.maxSize {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	return err
+}
+
+// Moves the current file aside and starts a new one. If the file can't be
+// moved it is reopened, so later events are still recorded and the next
+// rotation tries again.
+func (a *AuditSink) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return err
+	}
+	rotated := fmt.Sprintf("%s.%s", a.path, time.Now().UTC().Format("20060102T150405.000000000"))
+	if err := os.Rename(a.path, rotated); err != nil {
+		if openErr := a.open(); openErr != nil {
+			return fmt.Errorf("Error rotating audit file: %v, and reopening it: %v", err, openErr)
+		}
+		return fmt.Errorf("Error rotating audit file: %v", err)
+	}
+	return a.open()
+}
+
+// Close closes the audit file.
+func (a *AuditSink) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Returns the events recorded in the audit file at path.
+func auditRecords(t *testing.T, path string) []Event {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("Invalid audit record %q: %v", scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestAuditSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for _, name := range []string{"web-1", "web-2"} {
+		audit, err := NewAuditSink(path, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := audit.Record(Event{Kind: "Pod", Namespace: "default", Name: name, Reason: "Created"}); err != nil {
+			t.Fatal(err)
+		}
+		audit.Close()
+	}
+
+	events := auditRecords(t, path)
+	if len(events) != 2 || events[0].Name != "web-1" || events[1].Name != "web-2" {
+		t.Errorf("Got %+v, want web-1 then web-2", events)
+	}
+}
+
+func TestAuditRotates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	audit, err := NewAuditSink(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+	for _, name := range []string{"web-1", "web-2"} {
+		if err := audit.Record(Event{Kind: "Pod", Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if events := auditRecords(t, path); len(events) != 1 || events[0].Name != "web-2" {
+		t.Errorf("Got %+v in the current file, want only web-2", events)
+	}
+	if rotated, _ := filepath.Glob(path + ".*"); len(rotated) != 1 {
+		t.Errorf("Got rotated files %v, want 1", rotated)
+	}
+}
+
+func TestAuditKeepsRecordingWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := NewAuditSink(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+	if err := audit.Record(Event{Kind: "Pod", Name: "web-1"}); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing left to rename.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := audit.Record(Event{Kind: "Pod", Name: "web-2"}); err == nil {
+		t.Fatal("Got no error when the audit file couldn't be rotated")
+	}
+	if err := audit.Record(Event{Kind: "Pod", Name: "web-3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if events := auditRecords(t, path); len(events) != 1 || events[0].Name != "web-3" {
+		t.Errorf("Got %+v, want web-3 recorded in a reopened file", events)
+	}
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBackfill(t *testing.T) {
+	for _, backfill := range []bool{false, true} {
+		pod, deployment := newPod("web-1"), newDeployment("web")
+		pod.CreationTimestamp = meta_v1.NewTime(time.Now().Add(-time.Hour))
+		deployment.CreationTimestamp = pod.CreationTimestamp
+		tc := newTestController(t, Config{MaxCreateAge: time.Minute, Backfill: backfill}, pod, deployment)
+		tc.start()
+		if backfill {
+			tc.backfill()
+		}
+		tc.drain()
+
+		got := map[string]bool{}
+		for _, e := range tc.handler.Events() {
+			if e.Reason != "Created" {
+				t.Errorf("Got reason %s, want Created", e.Reason)
+			}
+			got[e.Kind+"/"+e.Name] = true
+		}
+		switch {
+		case backfill && (len(got) != 2 || !got["Pod/web-1"] || !got["Deployment/web"]):
+			t.Errorf("Got events for %v, want one each for Pod/web-1 and Deployment/web", got)
+		case !backfill && len(got) != 0:
+			t.Errorf("Got events for %v without backfill, want none", got)
+		}
+		if backfill && len(tc.handler.Events()) != 2 {
+			t.Errorf("Got %d events, want the initial list and backfill collapsed to 2", len(tc.handler.Events()))
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package controller
+
+// Handler wrapper which groups events into batches.
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Handler which can process several events at once. The error applies to
+// every event of the batch.
+type batchHandler interface {
+	HandleBatch(events []Event) error
+}
+
+// Handler which can process several events at once and reports the result of
+// each, e.g. a wrapper passing the batch on to handlers which fail only some.
+type batchResultHandler interface {
+	HandleBatch(events []Event) []error
+}
+
+// Passes events to h at once if it takes batches, otherwise one by one,
+// returning the result for each event.
+func handleBatch(h Handler, events []Event) []error {
+	if bh, ok := h.(batchResultHandler); ok {
+		return bh.HandleBatch(events)
+	}
+	errs := make([]error, len(events))
+	if bh, ok := h.(batchHandler); ok {
+		err := bh.HandleBatch(events)
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	for i, e := range events {
+		errs[i] = h.Handle(e)
+	}
+	return errs
+}
+
+// Combines the results of a batch into one error, or nil if all succeeded.
+func batchError(errs []error) error {
+	var failed int
+	var first error
+	for _, err := range errs {
+		if err != nil {
+			failed++
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d events failed: %v", failed, len(errs), first)
+}
+
+// Handler which returns before the outcome of an event is known, e.g. because
+// it sends events in batches later. SetDelivered is called once, when the
+// handler is passed to NewController, with the function each outcome must be
+// reported to.
+type deliveryReporter interface {
+	SetDelivered(delivered func(e Event, err error))
+}
+
+// BatchHandler accumulates events and flushes them to inner once maxBatch
+// events are pending or flushInterval elapses, whichever comes first. If inner
+// implements HandleBatch it receives the whole batch, otherwise Handle is called
+// once per event. Handle returns before the batch is sent; the outcome of each
+// event is reported to the controller, which retries failed events.
+type BatchHandler struct {
+	inner         Handler
+	maxBatch      int
+	flushInterval time.Duration
+
+	mu        sync.Mutex
+	pending   []Event
+	delivered func(Event, error)
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewBatchHandler returns a BatchHandler and starts its flush timer.
+func NewBatchHandler(inner Handler, maxBatch int, flushInterval time.Duration) (*BatchHandler, error) {
+	if maxBatch < 1 {
+		return nil, fmt.Errorf("Batch size must be at least 1")
+	}
+	if flushInterval <= 0 {
+		return nil, fmt.Errorf("Batch interval must be positive")
+	}
+	b := &BatchHandler{
+		inner:         inner,
+		maxBatch:      maxBatch,
+		flushInterval: flushInterval,
+		stopCh:        make(chan struct{}),
+		doneCh:        make(chan struct{}),
+	}
+	go b.run()
+	return b, nil
+}
+
+// SetDelivered sets the function the outcome of each event is reported to once
+// its batch is sent. Without one, failures are only logged.
+func (b *BatchHandler) SetDelivered(delivered func(e Event, err error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.delivered = delivered
+}
+
+// Handle queues the event, sending the batch if it is full.
+func (b *BatchHandler) Handle(e Event) error {
+	b.mu.Lock()
+	b.pending = append(b.pending, e)
+	if len(b.pending) < b.maxBatch {
+		b.mu.Unlock()
+		return nil
+	}
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	b.send(batch)
+	return nil
+}
+
+// Stop flushes any pending events and stops the flush timer.
+func (b *BatchHandler) Stop() {
+	b.stopOnce.Do(func() {
+		close(b.stopCh)
+		<-b.doneCh
+	})
+	stopHandler(b.inner)
+}
+
+func (b *BatchHandler) run() {
+	defer close(b.doneCh)
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.flush()
+		case <-b.stopCh:
+			b.flush()
+			return
+		}
+	}
+}
+
+// Sends all pending events.
+func (b *BatchHandler) flush() {
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	if len(batch) > 0 {
+		b.send(batch)
+	}
+}
+
+// Sends a batch and reports the outcome of each event.
+func (b *BatchHandler) send(batch []Event) {
+	errs := handleBatch(b.inner, batch)
+	b.mu.Lock()
+	delivered := b.delivered
+	b.mu.Unlock()
+	for i, e := range batch {
+		if delivered != nil {
+			delivered(e, errs[i])
+		} else if errs[i] != nil {
+			log.WithFields(log.Fields{
+				"namespace": e.Namespace,
+				"kind":      e.Kind,
+				"name":      e.Name,
+			}).WithError(errs[i]).Error("Error handling batched event")
+		}
+	}
+}
+
+// Reports the outcome of an event handed to a deliveryReporter. A failed event
+// is queued for another attempt, with backoff, until it runs out of retries.
+// Events which weren't queued, e.g. heartbeats, have no ID and aren't retried.
+func (c *Controller) delivered(e Event, err error) {
+	newEvent := event{
+		key:          e.Namespace + "/" + e.Name,
+		dedupKey:     e.ID,
+		namespace:    e.Namespace,
+		eventType:    "redeliver",
+		resourceType: e.Kind,
+		delivery:     &e,
+	}
+	if e.Namespace == "" {
+		newEvent.key = e.Name
+	}
+	key := newEvent.queueKey()
+	switch {
+	case err == nil:
+		c.queue.Forget(key)
+		c.firstSeen.Forget(key)
+	case e.ID == "":
+		c.logger.WithFields(newEvent.logFields()).WithError(err).Error("Error delivering event")
+	case c.queue.NumRequeues(key) >= maxRetries:
+		c.giveUp(key, newEvent, err)
+	default:
+		c.logger.WithFields(newEvent.logFields()).WithError(err).Error("Error delivering event (will retry)")
+		c.pending.Set(newEvent)
+		c.firstSeen.Add(key, time.Now())
+		c.queue.AddRateLimited(key)
+	}
+}
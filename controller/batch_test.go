@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Handler recording the batches it is passed.
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]Event
+}
+
+func (r *batchRecorder) Handle(e Event) error {
+	return r.HandleBatch([]Event{e})
+}
+
+func (r *batchRecorder) HandleBatch(events []Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, events)
+	return nil
+}
+
+func (r *batchRecorder) Batches() [][]Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]Event(nil), r.batches...)
+}
+
+func newTestBatchHandler(t *testing.T, inner Handler, maxBatch int, flushInterval time.Duration) *BatchHandler {
+	b, err := NewBatchHandler(inner, maxBatch, flushInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.Stop)
+	return b
+}
+
+func TestBatchFlushesWhenFull(t *testing.T) {
+	inner := &batchRecorder{}
+	b := newTestBatchHandler(t, inner, 3, time.Hour)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		b.Handle(Event{Name: name})
+	}
+	batches := inner.Batches()
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Errorf("Got batches %v, want one of 3 events", batches)
+	}
+}
+
+func TestBatchFlushesAfterInterval(t *testing.T) {
+	inner := &batchRecorder{}
+	b := newTestBatchHandler(t, inner, 100, 20*time.Millisecond)
+	b.Handle(Event{Name: "a"})
+	b.Handle(Event{Name: "b"})
+	deadline := time.Now().Add(time.Second)
+	for len(inner.Batches()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	batches := inner.Batches()
+	if len(batches) != 1 || len(batches[0]) != 2 {
+		t.Errorf("Got batches %v, want one of 2 events", batches)
+	}
+}
+
+func TestBatchFlushesOnStop(t *testing.T) {
+	inner := &batchRecorder{}
+	b := newTestBatchHandler(t, inner, 100, time.Hour)
+	b.Handle(Event{Name: "a"})
+	b.Stop()
+	if batches := inner.Batches(); len(batches) != 1 || len(batches[0]) != 1 {
+		t.Errorf("Got batches %v, want the pending event flushed", batches)
+	}
+}
+
+func TestBatchRejectsInvalidSettings(t *testing.T) {
+	for _, c := range []struct {
+		maxBatch int
+		interval time.Duration
+	}{{0, time.Second}, {10, 0}, {10, -time.Second}} {
+		if _, err := NewBatchHandler(&batchRecorder{}, c.maxBatch, c.interval); err == nil {
+			t.Errorf("Got no error for size %d and interval %s", c.maxBatch, c.interval)
+		}
+	}
+}
+
+// Handler failing events named bad.
+type rejectBad struct{}
+
+func (rejectBad) Handle(e Event) error {
+	if e.Name == "bad" {
+		return errors.New("rejected")
+	}
+	return nil
+}
+
+func TestBatchReportsEachEvent(t *testing.T) {
+	filter := func(inner Handler) Handler {
+		h, err := NewSeverityFilterHandler("Normal", inner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	breaker := func(inner Handler) Handler {
+		return NewCircuitBreakerHandler(inner, 10, time.Minute)
+	}
+	multi := func(inner Handler) Handler {
+		return NewMultiHandler(inner, &captureHandler{})
+	}
+	for name, inner := range map[string]Handler{
+		"handler":  rejectBad{},
+		"filter":   filter(rejectBad{}),
+		"breaker":  breaker(rejectBad{}),
+		"multi":    multi(rejectBad{}),
+		"wrappers": filter(breaker(multi(rejectBad{}))),
+	} {
+		b := newTestBatchHandler(t, inner, 3, time.Hour)
+		results := map[string]error{}
+		b.SetDelivered(func(e Event, err error) { results[e.Name] = err })
+		for _, name := range []string{"good", "bad", "fine"} {
+			b.Handle(Event{Name: name, Status: "Warning"})
+		}
+		if len(results) != 3 || results["good"] != nil || results["bad"] == nil || results["fine"] != nil {
+			t.Errorf("%s: got results %v, want only bad to fail", name, results)
+		}
+	}
+}
+
+func TestBatchFailuresRequeued(t *testing.T) {
+	tc := newTestController(t, Config{})
+	queue := NewFakeRateLimitingQueue()
+	tc.queue = queue
+	b := newTestBatchHandler(t, tc.handler, 1, time.Hour)
+	b.SetDelivered(tc.delivered)
+	tc.eventHandler = b
+
+	tc.handler.SetErr(errors.New("sink down"))
+	tc.add("Pod", newPod("web-0"))
+	tc.processNextItem()
+	if len(queue.RateLimited) != 1 {
+		t.Fatalf("Got AddRateLimited(%v), want the failed event requeued once", queue.RateLimited)
+	}
+
+	tc.handler.SetErr(nil)
+	key := queue.RateLimited[0]
+	queue.Add(key)
+	tc.processNextItem()
+	events := tc.handler.Events()
+	if len(events) != 1 || events[0].Name != "web-0" || events[0].Reason != "Created" {
+		t.Errorf("Got %+v, want the retried Created event", events)
+	}
+	if queue.NumRequeues(key) != 0 {
+		t.Error("Requeues not reset after the retry succeeded")
+	}
+}
@@ -0,0 +1,116 @@
+package controller
+
+// Circuit breaker which stops calling a failing handler for a cooldown period.
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreakerHandler wraps a handler and trips open after threshold consecutive failures.
+type CircuitBreakerHandler struct {
+	inner     Handler
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreakerHandler returns a closed circuit breaker around inner.
+func NewCircuitBreakerHandler(inner Handler, threshold int, cooldown time.Duration) *CircuitBreakerHandler {
+	return &CircuitBreakerHandler{
+		inner:     inner,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Handle passes the event to the inner handler unless the breaker is open.
+// While open it fails fast; once cooldown elapses a single probe is let through.
+func (b *CircuitBreakerHandler) Handle(e Event) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := b.inner.Handle(e)
+	b.record(err)
+	return err
+}
+
+// HandleBatch passes the events to the inner handler unless the breaker is
+// open, and returns the result of each event. The batch counts as one call,
+// failing if any of its events fails.
+func (b *CircuitBreakerHandler) HandleBatch(events []Event) []error {
+	if err := b.allow(); err != nil {
+		errs := make([]error, len(events))
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	errs := handleBatch(b.inner, events)
+	b.record(batchError(errs))
+	return errs
+}
+
+// Returns errCircuitOpen unless a call to the inner handler may go ahead.
+func (b *CircuitBreakerHandler) allow() error {
+	b.mu.Lock()
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			b.mu.Unlock()
+			circuitBreakerTripped.Inc()
+			return errCircuitOpen
+		}
+		log.Info("Circuit breaker half-open, probing handler")
+		b.state = breakerHalfOpen
+	case breakerHalfOpen:
+		// A probe is already in flight.
+		b.mu.Unlock()
+		circuitBreakerTripped.Inc()
+		return errCircuitOpen
+	}
+	b.mu.Unlock()
+	return nil
+}
+
+// Updates the breaker with the result of a call to the inner handler.
+func (b *CircuitBreakerHandler) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		if b.state == breakerHalfOpen {
+			log.Info("Circuit breaker closed")
+		}
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		log.Warnf("Circuit breaker open after %d consecutive failures: %v", b.failures, err)
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Stop stops the inner handler.
+func (b *CircuitBreakerHandler) Stop() {
+	stopHandler(b.inner)
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	sink := &captureHandler{}
+	sink.SetErr(errors.New("sink down"))
+	b := NewCircuitBreakerHandler(sink, 3, 20*time.Millisecond)
+	e := Event{Name: "web-1"}
+
+	for i := 0; i < 3; i++ {
+		if err := b.Handle(e); err == nil || err == errCircuitOpen {
+			t.Fatalf("Call %d got %v, want the sink's error", i+1, err)
+		}
+	}
+	if err := b.Handle(e); err != errCircuitOpen {
+		t.Fatalf("Got %v after 3 failures, want the breaker open", err)
+	}
+	if calls := sink.Calls(); calls != 3 {
+		t.Errorf("Sink called %d times, want 3", calls)
+	}
+
+	// A failed probe opens the breaker again.
+	time.Sleep(30 * time.Millisecond)
+	if err := b.Handle(e); err == nil || err == errCircuitOpen {
+		t.Fatalf("Got %v, want the probe to reach the sink", err)
+	}
+	if err := b.Handle(e); err != errCircuitOpen {
+		t.Fatalf("Got %v after a failed probe, want the breaker open", err)
+	}
+
+	// A successful probe closes it.
+	sink.SetErr(nil)
+	time.Sleep(30 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if err := b.Handle(e); err != nil {
+			t.Fatalf("Call %d got %v, want the breaker closed", i+1, err)
+		}
+	}
+}
+
+func TestCircuitBreakerResetsOnSuccess(t *testing.T) {
+	sink := &captureHandler{}
+	b := NewCircuitBreakerHandler(sink, 2, time.Minute)
+	e := Event{Name: "web-1"}
+
+	sink.SetErr(errors.New("sink down"))
+	b.Handle(e)
+	sink.SetErr(nil)
+	b.Handle(e)
+	sink.SetErr(errors.New("sink down"))
+	b.Handle(e)
+
+	if err := b.Handle(e); err == errCircuitOpen {
+		t.Error("Breaker opened on failures which weren't consecutive")
+	}
+}
@@ -0,0 +1,138 @@
+package controller
+
+// Firing and resolving of condition alerts, e.g. NodeNotReady.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Core Event reasons which clear a condition, and the condition they clear.
+var resolvingReasons = map[string]string{
+	"NodeReady": "NodeNotReady",
+}
+
+// Core Event reasons which raise a condition, cleared by a resolving reason.
+var conditionReasons = map[string]bool{
+	"NodeNotReady": true,
+}
+
+// Reason prefix of the events resolving a condition, so sinks with a notion of
+// resolving an alert, e.g. PagerDuty, can tell them apart.
+const resolvedReasonPrefix = "Resolved "
+
+// Active condition alerts by kind/namespace/name/condition.
+type conditionTracker struct {
+	mu     sync.Mutex
+	active map[string]Event
+}
+
+func newConditionTracker() *conditionTracker {
+	return &conditionTracker{active: map[string]Event{}}
+}
+
+func conditionKey(kind, namespace, name, condition string) string {
+	return strings.Join([]string{kind, namespace, name, condition}, "/")
+}
+
+// Fire records e as the active alert for condition of its object. A repeated
+// alert keeps the first one, so the resolution refers to when it started.
+func (t *conditionTracker) Fire(e Event, condition string) {
+	key := conditionKey(e.Kind, e.Namespace, e.Name, condition)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.active[key]; !ok {
+		t.active[key] = e
+	}
+}
+
+// Active returns the alert of condition of the object, if it is active.
+func (t *conditionTracker) Active(kind, namespace, name, condition string) (Event, bool) {
+	key := conditionKey(kind, namespace, name, condition)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.active[key]
+	return e, ok
+}
+
+// Resolve clears condition of the object, returning its alert if it was active.
+func (t *conditionTracker) Resolve(kind, namespace, name, condition string) (Event, bool) {
+	key := conditionKey(kind, namespace, name, condition)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.active[key]
+	delete(t.active, key)
+	return e, ok
+}
+
+// ActiveFor returns the active alerts of the object by condition.
+func (t *conditionTracker) ActiveFor(kind, namespace, name string) map[string]Event {
+	prefix := conditionKey(kind, namespace, name, "")
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	active := map[string]Event{}
+	for key, e := range t.active {
+		if strings.HasPrefix(key, prefix) {
+			active[strings.TrimPrefix(key, prefix)] = e
+		}
+	}
+	return active
+}
+
+// Builds the Normal event announcing that the alert fired for condition cleared.
+func resolvedEvent(fired Event, condition, why string) Event {
+	resolved := fired
+	resolved.ID = ""
+	resolved.Status = "Normal"
+	resolved.Reason = fmt.Sprintf("%s%s (firing since %s): %s", resolvedReasonPrefix, condition, fired.Timestamp.Format(time.RFC3339), why)
+	return resolved
+}
+
+// Returns the condition a resolution clears, or false if e resolves none.
+func resolvedCondition(e Event) (string, bool) {
+	if !strings.HasPrefix(e.Reason, resolvedReasonPrefix) {
+		return "", false
+	}
+	condition := strings.TrimPrefix(e.Reason, resolvedReasonPrefix)
+	if i := strings.Index(condition, " "); i >= 0 {
+		condition = condition[:i]
+	}
+	return condition, true
+}
+
+// Clears the condition e resolves, once e was delivered.
+func (c *Controller) resolutionDelivered(e Event) {
+	if condition, ok := resolvedCondition(e); ok {
+		c.conditions.Resolve(e.Kind, e.Namespace, e.Name, condition)
+	}
+}
+
+// Sends a Resolved event if condition of the object is active, reporting
+// whether it was. The condition is only cleared once the event is delivered,
+// by this call or a retry.
+func (c *Controller) resolveCondition(ctx context.Context, newEvent event, kind, namespace, name, condition, why string) (bool, error) {
+	fired, ok := c.conditions.Active(kind, namespace, name, condition)
+	if !ok {
+		return false, nil
+	}
+	if err := c.dispatch(ctx, newEvent, resolvedEvent(fired, condition, why)); err != nil {
+		return true, err
+	}
+	c.conditions.Resolve(kind, namespace, name, condition)
+	return true, nil
+}
+
+// Sends a Resolved event for every condition still active on a deleted
+// object, trying all of them even if some fail.
+func (c *Controller) resolveDeleted(ctx context.Context, newEvent event, kind, namespace, name string) error {
+	var firstErr error
+	for condition := range c.conditions.ActiveFor(kind, namespace, name) {
+		if _, err := c.resolveCondition(ctx, newEvent, kind, namespace, name, condition, "object deleted"); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+// Returns the core Event reporting reason for the Node named node.
+func newNodeEvent(node, reason string) *api_v1.Event {
+	ev := newCoreEvent(node, reason, api_v1.EventTypeNormal)
+	ev.InvolvedObject = api_v1.ObjectReference{Kind: "Node", Name: node}
+	return ev
+}
+
+func TestConditionFiresThenResolves(t *testing.T) {
+	tc := newTestController(t, Config{})
+	tc.add("Event", newNodeEvent("node-1", "NodeNotReady"))
+	tc.drain()
+	tc.add("Event", newNodeEvent("node-1", "NodeReady"))
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 2 {
+		t.Fatalf("Got %+v, want the alert and its resolution", events)
+	}
+	if fired := events[0]; fired.Status != "Danger" || fired.Reason != "NodeNotReady" {
+		t.Errorf("Got %s %s, want Danger NodeNotReady", fired.Status, fired.Reason)
+	}
+	resolved := events[1]
+	if resolved.Status != "Normal" || resolved.Name != "node-1" || !strings.HasPrefix(resolved.Reason, resolvedReasonPrefix+"NodeNotReady") {
+		t.Errorf("Got %s %s for %s, want node-1's NodeNotReady resolved", resolved.Status, resolved.Reason, resolved.Name)
+	}
+}
+
+func TestConditionResolvedOnDelete(t *testing.T) {
+	tc := newTestController(t, Config{})
+	running := inPhase(newPod("web-1"), api_v1.PodRunning)
+	tc.add("Pod", running)
+	tc.drain()
+	crashing := inCrashLoop(running, 5)
+	tc.update("Pod", running, crashing)
+	tc.drain()
+	tc.remove("Pod", crashing)
+	tc.drain()
+
+	var reasons []string
+	for _, e := range tc.handler.Events() {
+		reasons = append(reasons, e.Reason)
+	}
+	var fired, resolved bool
+	for _, reason := range reasons {
+		fired = fired || strings.HasPrefix(reason, crashLoopBackOff)
+		resolved = resolved || strings.HasPrefix(reason, resolvedReasonPrefix+crashLoopBackOff) && strings.HasSuffix(reason, "object deleted")
+	}
+	if !fired || !resolved {
+		t.Errorf("Got reasons %q, want the crash loop fired and resolved by the delete", reasons)
+	}
+	if _, ok := tc.conditions.Resolve("Pod", "default", "web-1", crashLoopBackOff); ok {
+		t.Error("Condition still active after the delete")
+	}
+}
+
+func TestCrashLoopResolvedWithPhaseChange(t *testing.T) {
+	tc := newTestController(t, Config{})
+	running := inPhase(newPod("web-1"), api_v1.PodRunning)
+	tc.add("Pod", running)
+	tc.drain()
+	crashing := inCrashLoop(running, 5)
+	tc.update("Pod", running, crashing)
+	tc.drain()
+	failed := inPhase(running, api_v1.PodFailed)
+	tc.update("Pod", crashing, failed)
+	tc.drain()
+
+	var resolved, phaseChanged bool
+	for _, e := range tc.handler.Events() {
+		resolved = resolved || strings.HasPrefix(e.Reason, resolvedReasonPrefix+crashLoopBackOff)
+		phaseChanged = phaseChanged || e.Kind == "PodPhaseChange" && e.Reason == "Running→Failed"
+	}
+	if !resolved || !phaseChanged {
+		t.Errorf("Got %+v, want the crash loop resolved and the phase change", tc.handler.Events())
+	}
+	if _, ok := tc.conditions.Active("Pod", "default", "web-1", crashLoopBackOff); ok {
+		t.Error("Condition still active after the pod stopped crash looping")
+	}
+}
+
+// Handler failing resolutions while err is set, and passing every other
+// event to inner.
+type failResolutions struct {
+	inner Handler
+
+	mu  sync.Mutex
+	err error
+}
+
+func (h *failResolutions) Handle(e Event) error {
+	h.mu.Lock()
+	err := h.err
+	h.mu.Unlock()
+	if err != nil && strings.HasPrefix(e.Reason, resolvedReasonPrefix) {
+		return err
+	}
+	return h.inner.Handle(e)
+}
+
+func (h *failResolutions) SetErr(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.err = err
+}
+
+func TestDeleteSentWhenResolvingFails(t *testing.T) {
+	tc := newTestController(t, Config{})
+	queue := NewFakeRateLimitingQueue()
+	tc.queue = queue
+	resolutions := &failResolutions{inner: tc.handler, err: errors.New("sink down")}
+	tc.eventHandler = resolutions
+	running := inPhase(newPod("web-1"), api_v1.PodRunning)
+	tc.add("Pod", running)
+	tc.drain()
+	crashing := inCrashLoop(running, 5)
+	tc.update("Pod", running, crashing)
+	tc.drain()
+	tc.remove("Pod", crashing)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if last := events[len(events)-1]; last.Reason != "Deleted" {
+		t.Fatalf("Got %+v, want the Deleted event sent although the resolution failed", events)
+	}
+	if len(queue.RateLimited) != 1 {
+		t.Fatalf("Got AddRateLimited(%v), want the delete retried", queue.RateLimited)
+	}
+	if _, ok := tc.conditions.Active("Pod", "default", "web-1", crashLoopBackOff); !ok {
+		t.Fatal("Condition cleared although its resolution failed")
+	}
+
+	resolutions.SetErr(nil)
+	queue.Add(queue.RateLimited[0])
+	tc.drain()
+
+	var resolved int
+	for _, e := range tc.handler.Events() {
+		if strings.HasPrefix(e.Reason, resolvedReasonPrefix+crashLoopBackOff) {
+			resolved++
+		}
+	}
+	if resolved != 1 {
+		t.Errorf("Got %d resolutions, want 1 from the retry", resolved)
+	}
+	if _, ok := tc.conditions.Active("Pod", "default", "web-1", crashLoopBackOff); ok {
+		t.Error("Condition still active after its resolution was delivered")
+	}
+}
@@ -0,0 +1,975 @@
+package controller
+
+// Kubernetes Controller which demonstrates multiple "state gates".
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	api_v1 "k8s.io/api/core/v1"
+	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+	rbac_v1beta1 "k8s.io/api/rbac/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const maxRetries = 5
+
+// Event is an alert about a Kubernetes object, as passed to a Handler.
+type Event struct {
+	// ID is the same every time one change of an object is reported, e.g.
+	// on retries, so consumers can deduplicate.
+	ID        string
+	Namespace string
+	Kind      string
+	Component string
+	Host      string
+	Reason    string
+	Status    string
+	Name      string
+	Owner     string
+	Labels    map[string]string
+	Timestamp time.Time
+}
+
+// Event indicate the informerEvent
+type event struct {
+	key string
+	// dedupKey, if set, replaces key as the identity of the event in the queue
+	dedupKey     string
+	eventType    string
+	namespace    string
+	resourceType string
+	// backfill events are synthesized for objects which existed at startup
+	backfill bool
+	// oldObj is the object before an update
+	oldObj interface{}
+	// lastObj is the last known state of the object: as the informer
+	// delivered it, or as it was deleted
+	lastObj interface{}
+	// resourceVersion of the object, once fetched
+	resourceVersion string
+	// objectMeta of the object, once fetched, to resolve its owner from
+	objectMeta meta_v1.ObjectMeta
+	// delivery is the Event to send again, for redeliver events, or to send
+	// at last, for release events
+	delivery *Event
+}
+
+// Structured log fields describing the event.
+func (e event) logFields() log.Fields {
+	namespace, name, err := cache.SplitMetaNamespaceKey(e.key)
+	if err != nil {
+		name = e.key
+	}
+	if e.namespace != "" {
+		namespace = e.namespace
+	}
+	return log.Fields{
+		"namespace": namespace,
+		"kind":      e.resourceType,
+		"name":      name,
+		"eventType": e.eventType,
+	}
+}
+
+// Handler processes an event.
+type Handler interface {
+	Handle(e Event) error
+}
+
+// Handler which discards every event.
+type noopHandler struct{}
+
+func (noopHandler) Handle(e Event) error {
+	return nil
+}
+
+// Config holds the controller's tunable settings.
+type Config struct {
+	// Namespace restricts watches to one namespace, so a namespaced Role is
+	// enough RBAC. Empty watches all namespaces and needs a ClusterRole.
+	Namespace string
+	// ResyncPeriod is how often informers re-deliver every cached object as an
+	// update; 0 disables resync. The objects are unchanged, so these updates
+	// are dropped without alerting.
+	ResyncPeriod time.Duration
+	// MetricsAddr is the listen address for /metrics, /events and /status; empty disables the server.
+	MetricsAddr string
+	// EventHistorySize is how many recent events /events can return.
+	EventHistorySize int
+	// AlertsPerMinute caps the alerts sent for any single object; 0 is unlimited.
+	AlertsPerMinute int
+	// MaxWatchErrors is how many consecutive watch failures stop the controller; 0 never stops.
+	MaxWatchErrors int
+	// IgnoreAnnotation silences alerts for objects where it is set to a true value.
+	IgnoreAnnotation string
+	// MinCreateAge and MaxCreateAge bound the age of objects which raise create
+	// alerts. Older objects existed before the controller started and are skipped.
+	// A zero MaxCreateAge has no upper bound.
+	MinCreateAge time.Duration
+	MaxCreateAge time.Duration
+	// RetryBaseDelay and RetryMaxDelay bound the exponential backoff of failed items.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// QueueQPS and QueueBurst configure the overall token bucket of the workqueue.
+	QueueQPS   float64
+	QueueBurst int
+	// RetryJitter adds a random delay of up to this fraction of each backoff.
+	RetryJitter float64
+	// MaxItemAge gives up on an event this long after it was first queued,
+	// however few retries it had; 0 only limits the retry count.
+	MaxItemAge time.Duration
+	// Backfill emits a create event for every existing object once caches sync,
+	// regardless of the create age window.
+	Backfill bool
+	// Workers is the number of goroutines processing the queue, at least 1.
+	Workers int
+	// UpdateWatchFields lists the field paths, e.g. "spec.replicas", whose
+	// change raises an update alert. Empty alerts on any spec change.
+	UpdateWatchFields []string
+	// WatchedReasons and WatchedTypes limit which core Events alert, e.g.
+	// BackOff or Warning. Empty allows all.
+	WatchedReasons []string
+	WatchedTypes   []string
+	// RolloutWindow, if set, replaces a delete followed within the window by a
+	// create under the same owner with a single RolloutProgress event.
+	RolloutWindow time.Duration
+	// Indexers are added to informers built by NewInformer, e.g.
+	// ByOwnerIndex: OwnerIndexFunc.
+	Indexers cache.Indexers
+	// KeyFunc, if set, keys the work queue instead of namespace/name, so
+	// events for objects sharing a key are processed once.
+	KeyFunc cache.KeyFunc
+	// GiveUpPolicy applies to events which ran out of retries or MaxItemAge.
+	GiveUpPolicy GiveUpPolicy
+	// MetricsNamespaces, if set, are the namespaces given their own label in
+	// events_total. Events from any other namespace are counted as "other".
+	MetricsNamespaces []string
+	// RestartThreshold is the number of restarts a crash looping pod may
+	// have without alerting. 0 alerts on the first crash loop.
+	RestartThreshold int
+	// MinObjectAge holds back crash loop alerts for pods younger than this.
+	MinObjectAge time.Duration
+	// Watches scope individual resource types. Resources without a spec use
+	// Namespace and no label selector.
+	Watches []WatchSpec
+	// SeverityRules override the status of events by resource type, or
+	// AllResources, and then by reason, e.g. Deleted, or event type: create,
+	// update or delete. Reasons take precedence.
+	SeverityRules map[string]map[string]string
+	// PprofAddr, if set, is the listen address for the /debug/pprof handlers.
+	PprofAddr string
+	// HeartbeatInterval, if set, is how often a Heartbeat event is sent.
+	HeartbeatInterval time.Duration
+	// ShutdownTimeout bounds how long Run waits for queued events on
+	// shutdown. 0 waits until they are all processed.
+	ShutdownTimeout time.Duration
+	// OwnerCacheSize and OwnerCacheTTL bound the cache of resolved owners.
+	// Zero uses DefaultOwnerCacheSize and DefaultOwnerCacheTTL.
+	OwnerCacheSize int
+	OwnerCacheTTL  time.Duration
+	// IgnoreManagedBy drops events of objects written by one of these field
+	// managers, e.g. an operator, or owned by an object of one of these kinds.
+	IgnoreManagedBy []string
+}
+
+// Validate checks the config for inconsistent settings.
+func (config Config) Validate() error {
+	baseDelay, maxDelay := config.RetryBaseDelay, config.RetryMaxDelay
+	if baseDelay == 0 {
+		baseDelay = DefaultRetryBaseDelay
+	}
+	if maxDelay == 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+	if baseDelay >= maxDelay {
+		return fmt.Errorf("Retry base delay %s must be less than max delay %s", baseDelay, maxDelay)
+	}
+	if config.QueueQPS < 0 || config.QueueBurst < 0 {
+		return fmt.Errorf("Queue QPS and burst must not be negative")
+	}
+	if config.RetryJitter < 0 {
+		return fmt.Errorf("Retry jitter must not be negative")
+	}
+	for _, spec := range config.Watches {
+		if err := spec.validate(); err != nil {
+			return err
+		}
+	}
+	if err := validSeverityRules(config.SeverityRules); err != nil {
+		return err
+	}
+	return config.GiveUpPolicy.validate()
+}
+
+// Informer tagged with the resourceType of the objects it watches.
+type resourceInformer struct {
+	resourceType string
+	informer     cache.SharedIndexInformer
+}
+
+// Controller object.
+type Controller struct {
+	logger       *log.Entry
+	clientset    kubernetes.Interface
+	queue        workqueue.RateLimitingInterface
+	informers    []resourceInformer
+	eventHandler Handler
+	configMu     sync.RWMutex
+	config       Config
+	effective    map[string]string
+	history      *eventHistory
+	throttle     *alertThrottle
+	owners       *ownerResolver
+	audit        *AuditSink
+	deadLetter   Handler
+	firstSeen    *firstSeenTracker
+	pending      *pendingEvents
+	versions     *versionTracker
+	rollouts     *rolloutDetector
+	conditions   *conditionTracker
+
+	// Closed when the controller must stop because of an unrecoverable error.
+	abortCh   chan struct{}
+	abortOnce sync.Once
+	abortErr  error
+}
+
+// NewInformer builds a shared informer for objType using the configured resync period.
+func NewInformer(lw cache.ListerWatcher, objType runtime.Object, config Config) cache.SharedIndexInformer {
+	indexers := cache.Indexers{}
+	for name, indexFunc := range config.Indexers {
+		indexers[name] = indexFunc
+	}
+	return cache.NewSharedIndexInformer(
+		lw,
+		objType,
+		config.ResyncPeriod,
+		indexers,
+	)
+}
+
+// NewController returns a Controller with an empty rate limited queue. Without
+// an eventHandler events are processed but discarded.
+func NewController(clientset kubernetes.Interface, eventHandler Handler, config Config) *Controller {
+	logger := log.WithField("pkg", "k8s-controller")
+	if eventHandler == nil {
+		logger.Warn("No event handler configured, events will be discarded")
+		eventHandler = noopHandler{}
+	}
+	c := &Controller{
+		logger:       logger,
+		clientset:    clientset,
+		queue:        workqueue.NewRateLimitingQueue(newRateLimiter(config)),
+		eventHandler: eventHandler,
+		config:       config,
+		history:      newEventHistory(config.EventHistorySize),
+		throttle:     newAlertThrottle(config.AlertsPerMinute),
+		owners:       newOwnerResolver(clientset, config.OwnerCacheSize, config.OwnerCacheTTL),
+		firstSeen:    newFirstSeenTracker(),
+		pending:      newPendingEvents(),
+		versions:     newVersionTracker(),
+		conditions:   newConditionTracker(),
+		abortCh:      make(chan struct{}),
+	}
+	if config.RolloutWindow > 0 {
+		c.rollouts = newRolloutDetector(config.RolloutWindow, c.releaseDelete)
+	}
+	if r, ok := eventHandler.(deliveryReporter); ok {
+		r.SetDelivered(c.delivered)
+	}
+	return c
+}
+
+// SetAuditSink records every event the controller produces to sink, before any
+// throttling, so the audit trail is complete even when alerts are dropped.
+func (c *Controller) SetAuditSink(sink *AuditSink) {
+	c.audit = sink
+}
+
+// Stops the controller with err. Only the first error is kept.
+func (c *Controller) abort(err error) {
+	c.abortOnce.Do(func() {
+		c.logger.WithError(err).Error("Stopping custom controller")
+		c.abortErr = err
+		close(c.abortCh)
+	})
+}
+
+// AddInformer registers an informer whose events are queued with the given resourceType.
+func (c *Controller) AddInformer(resourceType string, informer cache.SharedIndexInformer) {
+	// Add an event Handler to the informer.
+	informer.AddEventHandler(c.informerHandler(resourceType))
+	c.informers = append(c.informers, resourceInformer{resourceType: resourceType, informer: informer})
+	setHandlerIndexer(c.eventHandler, resourceType, informer.GetIndexer())
+}
+
+// Returns the informer event handler queueing events of resourceType.
+func (c *Controller) informerHandler(resourceType string) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			key, dedupKey, err := c.objectKeys(obj)
+			if err == nil {
+				c.enqueue(event{key: key, dedupKey: dedupKey, eventType: "create", resourceType: resourceType, lastObj: obj})
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			if !c.updateWatched(old, new) {
+				return
+			}
+			key, dedupKey, err := c.objectKeys(new)
+			if err == nil {
+				c.enqueue(event{key: key, dedupKey: dedupKey, eventType: "update", resourceType: resourceType, oldObj: old, lastObj: new})
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			key, dedupKey, err := c.objectKeys(obj)
+			if err == nil {
+				c.enqueue(event{key: key, dedupKey: dedupKey, eventType: "delete", resourceType: resourceType, lastObj: obj})
+			}
+		},
+	}
+}
+
+// Reports whether an update can raise an alert. Resyncs, which re-deliver an
+// object at the same resource version, never can. Pod phase and crash loop
+// changes, Secret and ConfigMap data changes and core Events always can, since
+// they have no spec; other objects only when a watched field changed.
+func (c *Controller) updateWatched(old, new interface{}) bool {
+	if isResync(old, new) {
+		return false
+	}
+	if _, _, changed := podPhaseChange(old, new); changed {
+		return true
+	}
+	if _, _, entered := podCrashLoop(old, new); entered || podCrashLoopCleared(old, new) {
+		return true
+	}
+	if _, _, changed, _ := dataChange(old, new); changed {
+		return true
+	}
+	if _, isCoreEvent := new.(*api_v1.Event); isCoreEvent {
+		return true
+	}
+	return fieldsChanged(old, new, c.settings().UpdateWatchFields)
+}
+
+// Reports whether an update re-delivers an object at the same resource version.
+func isResync(old, new interface{}) bool {
+	oldMeta, err := meta.Accessor(old)
+	if err != nil {
+		return false
+	}
+	newMeta, err := meta.Accessor(new)
+	if err != nil {
+		return false
+	}
+	return oldMeta.GetResourceVersion() != "" && oldMeta.GetResourceVersion() == newMeta.GetResourceVersion()
+}
+
+// Queues the key of an event, remembering when it was first queued. If the key
+// is already queued the event waits behind the others for it, so bursts of
+// updates for one object are processed once, with the latest state.
+func (c *Controller) enqueue(e event) {
+	key := c.pending.Set(e)
+	c.firstSeen.Add(key, time.Now())
+	c.queue.Add(key)
+}
+
+// Returns the informer registered for resourceType, or nil.
+func (c *Controller) informerFor(resourceType string) cache.SharedIndexInformer {
+	for _, ri := range c.informers {
+		if ri.resourceType == resourceType {
+			return ri.informer
+		}
+	}
+	return nil
+}
+
+// Run starts the controller. It returns when stopCh is closed, or with an error
+// if the controller had to stop itself.
+func (c *Controller) Run(stopCh <-chan struct{}) error {
+	// Don't crash on panic.
+	defer utilruntime.HandleCrash()
+	// Ensure existing workers are exited before we start.
+	defer c.queue.ShutDown()
+
+	c.logger.Info("Starting custom controller")
+
+	// Stop informers and workers on either an external stop or an abort.
+	runCh := make(chan struct{})
+	go func() {
+		select {
+		case <-stopCh:
+		case <-c.abortCh:
+		}
+		close(runCh)
+	}()
+
+	if c.config.MetricsAddr != "" {
+		go c.serveHTTP(c.config.MetricsAddr)
+	}
+	if c.config.PprofAddr != "" {
+		go c.servePprof(c.config.PprofAddr)
+	}
+
+	for _, ri := range c.informers {
+		go ri.informer.Run(runCh)
+	}
+	// Sync all caches before starting.
+	if !cache.WaitForCacheSync(runCh, c.HasSynced) {
+		if c.abortErr != nil {
+			return c.abortErr
+		}
+		utilruntime.HandleError(fmt.Errorf("Timed out waiting for caches to sync"))
+		return nil
+	}
+
+	c.logger.Info("Custom controller synced and ready")
+
+	if c.config.HeartbeatInterval > 0 {
+		go c.heartbeat(c.config.HeartbeatInterval, runCh)
+	}
+
+	if c.config.Backfill {
+		c.backfill()
+	}
+
+	workers := c.config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	var running sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		running.Add(1)
+		// runWorker is an infinite loop. If anything comes up in stopCh it will be killed after 1 second.
+		go func() {
+			defer running.Done()
+			wait.Until(c.runWorker, time.Second, runCh)
+		}()
+	}
+	<-runCh
+
+	c.drain(&running, c.config.ShutdownTimeout)
+	if c.audit != nil {
+		c.audit.Close()
+	}
+	return c.abortErr
+}
+
+// Queues a create event for every object already in the informer caches.
+func (c *Controller) backfill() {
+	for _, ri := range c.informers {
+		for _, obj := range ri.informer.GetIndexer().List() {
+			key, dedupKey, err := c.objectKeys(obj)
+			if err != nil {
+				continue
+			}
+			c.enqueue(event{key: key, dedupKey: dedupKey, eventType: "create", resourceType: ri.resourceType, backfill: true, lastObj: obj})
+		}
+	}
+	c.logger.Info("Queued backfill events for existing objects")
+}
+
+// HasSynced is required for the cache.Controller interface.
+func (c *Controller) HasSynced() bool {
+	for _, ri := range c.informers {
+		if !ri.informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Controller) runWorker() {
+	for c.processNextItem() {
+		// loop forever.
+	}
+}
+
+// Pulls a key off the top of the queue, processes it and either requeues or marks as done.
+func (c *Controller) processNextItem() bool {
+	item, quit := c.queue.Get()
+
+	if quit {
+		return false
+	}
+	defer c.queue.Done(item)
+	key := item.(string)
+	newEvent, ok := c.pending.Take(key)
+	if !ok {
+		// Nothing new since the key was last processed.
+		c.queue.Forget(key)
+		return true
+	}
+	// Actually process the item. This is where the magic happens.
+	err := c.safeProcessItem(newEvent)
+	maxItemAge := c.settings().MaxItemAge
+	if err == nil {
+		// No error, reset the NumRequeues counter. The outcome of a redelivery
+		// to a deliveryReporter is only known once it is reported to delivered.
+		if _, reports := c.eventHandler.(deliveryReporter); newEvent.eventType != "redeliver" || !reports {
+			c.queue.Forget(key)
+			c.firstSeen.Forget(key)
+		}
+	} else if maxItemAge > 0 && c.firstSeen.Age(key, time.Now()) > maxItemAge {
+		// Error and queued for too long
+		c.giveUp(key, newEvent, fmt.Errorf("Queued longer than %s: %v", maxItemAge, err))
+		utilruntime.HandleError(err)
+	} else if c.queue.NumRequeues(key) < maxRetries {
+		c.logger.WithFields(newEvent.logFields()).WithError(err).Error("Error processing event (will retry)")
+		if hErr, ok := err.(*handlerError); ok {
+			newEvent.eventType = "redeliver"
+			newEvent.delivery = &hErr.delivery
+		}
+		c.pending.Restore(newEvent)
+		c.queue.AddRateLimited(key)
+		return true
+	} else {
+		// Error and too many retries
+		c.giveUp(key, newEvent, err)
+		utilruntime.HandleError(err)
+	}
+	// Later events for the key wait their turn.
+	if c.pending.Has(key) {
+		c.firstSeen.Add(key, time.Now())
+		c.queue.Add(key)
+	}
+	return true
+}
+
+// This is where the magic happens.
+func (c *Controller) processItem(newEvent event) error {
+	ctx, span := tracer().Start(context.Background(), "processItem", trace.WithAttributes(
+		attribute.String("key", newEvent.key),
+		attribute.String("resourceType", newEvent.resourceType),
+		attribute.String("eventType", newEvent.eventType),
+	))
+	defer span.End()
+
+	// events handed back by the handler only need handling again
+	if newEvent.eventType == "redeliver" {
+		if err := c.handle(ctx, *newEvent.delivery); err != nil {
+			return err
+		}
+		c.resolutionDelivered(*newEvent.delivery)
+		return nil
+	}
+	// deletes held for rollout detection are ready to deliver
+	if newEvent.eventType == "release" {
+		newEvent.eventType = "delete"
+		return c.deliver(ctx, newEvent, *newEvent.delivery)
+	}
+
+	informer := c.informerFor(newEvent.resourceType)
+	if informer == nil {
+		return fmt.Errorf("No informer registered for resource type %s", newEvent.resourceType)
+	}
+	obj, exists, err := informer.GetIndexer().GetByKey(newEvent.key)
+	if err != nil {
+		return fmt.Errorf("Error fetching object with key %s from store: %v", newEvent.key, err)
+	}
+	if !exists {
+		// objects deleted since a create or update was queued are reported as
+		// the informer delivered them while their delete event waits; with the
+		// delete already processed there is nothing left to report
+		if newEvent.eventType != "delete" && !c.pending.Deleting(newEvent.queueKey()) {
+			c.logger.WithFields(newEvent.logFields()).Debug("Object no longer exists, skipping")
+			return nil
+		}
+		// deleted objects are gone from the store, fall back to their last known state
+		obj = newEvent.lastObj
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+	}
+
+	// get object's metedata
+	objectMeta := getObjectMetaData(obj)
+	c.versions.Observe(newEvent.resourceType, objectMeta.ResourceVersion)
+	newEvent.resourceVersion = objectMeta.ResourceVersion
+	newEvent.objectMeta = objectMeta
+
+	// skip objects outside the resource's watch spec
+	if !c.settings().WatchSpec(newEvent.resourceType).matches(objectMeta) {
+		c.logger.WithFields(newEvent.logFields()).Debug("Object outside watch spec")
+		return nil
+	}
+
+	// skip objects which opted out of alerting
+	if c.isIgnored(objectMeta) {
+		c.logger.WithFields(newEvent.logFields()).Debug("Object opted out of alerting")
+		return nil
+	}
+
+	// skip objects from ignored managers
+	if manager, ok := managedBy(objectMeta, c.settings().IgnoreManagedBy); ok {
+		c.logger.WithFields(newEvent.logFields()).WithField("manager", manager).Debug("Object managed by ignored manager")
+		return nil
+	}
+
+	// core Events describe another object
+	if coreEvent, ok := obj.(*api_v1.Event); ok {
+		return c.processCoreEvent(ctx, newEvent, coreEvent)
+	}
+
+	// the owner of a deleted object won't be asked for again
+	if newEvent.eventType == "delete" {
+		defer c.owners.Forget(objectMeta.UID)
+	}
+
+	timestamp := eventTimestamp(obj, time.Now())
+	host := eventHost(obj)
+
+	// hold status type for default critical alerts
+	var status string
+
+	// namespace retrived from event key incase namespace value is empty.
+	// Keys of cluster-scoped objects, e.g. Nodes, are just the name.
+	namespace, name, err := cache.SplitMetaNamespaceKey(newEvent.key)
+	if err != nil {
+		return fmt.Errorf("Error parsing key %s: %v", newEvent.key, err)
+	}
+	if newEvent.namespace == "" {
+		newEvent.namespace = namespace
+	}
+	newEvent.key = name
+	c.logger.WithFields(log.Fields{
+		"key":       newEvent.key,
+		"namespace": newEvent.namespace,
+	}).Debug("Resolved event namespace")
+	// @todo: adapt events for Deployments.
+	// process events based on its type
+	switch newEvent.eventType {
+	case "create":
+		// alert only on newly created objects, not ones listed on startup
+		if newEvent.backfill || c.isFreshCreate(objectMeta, time.Now()) {
+			switch newEvent.resourceType {
+			case "NodeNotReady":
+				status = "Danger"
+			case "NodeReady":
+				status = "Normal"
+			case "NodeRebooted":
+				status = "Danger"
+			case "Backoff":
+				status = "Danger"
+			default:
+				status = "Normal"
+			}
+			kbEvent := Event{
+				Name:      objectMeta.Name,
+				Namespace: newEvent.namespace,
+				Kind:      newEvent.resourceType,
+				Status:    status,
+				Reason:    "Created",
+				Host:      host,
+				Labels:    objectMeta.Labels,
+				Timestamp: timestamp,
+			}
+			return c.dispatch(ctx, newEvent, kbEvent)
+		}
+	case "update":
+		// pods alert on phase transitions and crash loops only. The update
+		// which ends a crash loop can also change the phase, so the crash loop
+		// is resolved first.
+		var resolveErr error
+		if podCrashLoopCleared(newEvent.oldObj, obj) {
+			_, resolveErr = c.resolveCondition(ctx, newEvent, "Pod", newEvent.namespace, newEvent.key, crashLoopBackOff, "no container is crash looping")
+		}
+		if from, to, changed := podPhaseChange(newEvent.oldObj, obj); changed {
+			kbEvent := Event{
+				Name:      newEvent.key,
+				Namespace: newEvent.namespace,
+				Kind:      "PodPhaseChange",
+				Status:    podPhaseStatus(to),
+				Reason:    fmt.Sprintf("%s→%s", from, to),
+				Host:      host,
+				Labels:    objectMeta.Labels,
+				Timestamp: timestamp,
+			}
+			return joinErrors(resolveErr, c.dispatch(ctx, newEvent, kbEvent))
+		}
+		if container, restarts, entered := podCrashLoop(newEvent.oldObj, obj); entered {
+			if !c.crashLoopAlertable(obj.(*api_v1.Pod), restarts, time.Now()) {
+				return nil
+			}
+			kbEvent := Event{
+				Name:      newEvent.key,
+				Namespace: newEvent.namespace,
+				Kind:      "Pod",
+				Status:    "Danger",
+				Reason:    fmt.Sprintf("%s: container %s restarted %d times", crashLoopBackOff, container, restarts),
+				Host:      host,
+				Labels:    objectMeta.Labels,
+				Timestamp: timestamp,
+			}
+			c.conditions.Fire(kbEvent, crashLoopBackOff)
+			return c.dispatch(ctx, newEvent, kbEvent)
+		}
+		if _, isPod := obj.(*api_v1.Pod); isPod {
+			return resolveErr
+		}
+		// secrets and config maps alert on data changes only
+		if kind, reason, changed, isData := dataChange(newEvent.oldObj, obj); isData {
+			if !changed {
+				return nil
+			}
+			kbEvent := Event{
+				Name:      newEvent.key,
+				Namespace: newEvent.namespace,
+				Kind:      kind,
+				Status:    "Warning",
+				Reason:    reason,
+				Host:      host,
+				Labels:    objectMeta.Labels,
+				Timestamp: timestamp,
+			}
+			return c.dispatch(ctx, newEvent, kbEvent)
+		}
+		/* TODOs
+		- enahace update event processing in such a way that, it send alerts about what got changed.
+		*/
+		switch newEvent.resourceType {
+		case "Backoff":
+			status = "Danger"
+		default:
+			status = "Warning"
+		}
+		kbEvent := Event{
+			Name:      newEvent.key,
+			Namespace: newEvent.namespace,
+			Kind:      newEvent.resourceType,
+			Status:    status,
+			Reason:    "Updated",
+			Host:      host,
+			Labels:    objectMeta.Labels,
+			Timestamp: timestamp,
+		}
+		return c.dispatch(ctx, newEvent, kbEvent)
+	case "delete":
+		// The Deleted event is sent even if resolving fails, and the retry
+		// resends only the resolutions which failed.
+		resolveErr := c.resolveDeleted(ctx, newEvent, newEvent.resourceType, newEvent.namespace, newEvent.key)
+		// Danger unless a severity rule says otherwise, e.g. Job.delete=Normal
+		kbEvent := Event{
+			Name:      newEvent.key,
+			Namespace: newEvent.namespace,
+			Kind:      newEvent.resourceType,
+			Status:    "Danger",
+			Reason:    "Deleted",
+			Host:      host,
+			Labels:    objectMeta.Labels,
+			Timestamp: timestamp,
+		}
+		return joinErrors(resolveErr, c.dispatch(ctx, newEvent, kbEvent))
+	}
+	return nil
+}
+
+// Reports whether the object's age at now is within the configured create window.
+func (c *Controller) isFreshCreate(objectMeta meta_v1.ObjectMeta, now time.Time) bool {
+	settings := c.settings()
+	age := now.Sub(objectMeta.CreationTimestamp.Time)
+	if age < settings.MinCreateAge {
+		return false
+	}
+	return settings.MaxCreateAge == 0 || age <= settings.MaxCreateAge
+}
+
+// Returns the first of managers which wrote fields of the object, or is the
+// kind of one of its owners.
+func managedBy(objectMeta meta_v1.ObjectMeta, managers []string) (string, bool) {
+	for _, manager := range managers {
+		for _, entry := range objectMeta.ManagedFields {
+			if entry.Manager == manager {
+				return manager, true
+			}
+		}
+		for _, ref := range objectMeta.OwnerReferences {
+			if ref.Kind == manager {
+				return manager, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Reports whether the object carries a truthy ignore annotation.
+func (c *Controller) isIgnored(objectMeta meta_v1.ObjectMeta) bool {
+	annotation := c.settings().IgnoreAnnotation
+	if annotation == "" {
+		return false
+	}
+	value, ok := objectMeta.Annotations[annotation]
+	if !ok {
+		return false
+	}
+	ignore, err := strconv.ParseBool(value)
+	return err == nil && ignore
+}
+
+// Passes a fully formed Event to the event handler.
+func (c *Controller) dispatch(ctx context.Context, newEvent event, kbEvent Event) error {
+	kbEvent.Status = c.settings().severity(newEvent.resourceType, newEvent.eventType, kbEvent)
+	// resolving the owner can cost an API request, so only events which are
+	// sent, or grouped into rollouts by owner, are attributed to one
+	if kbEvent.Owner == "" && (c.rollouts != nil || handlerWants(c.eventHandler, kbEvent)) {
+		owner, err := c.owners.Resolve(ctx, newEvent.objectMeta)
+		if err != nil {
+			c.logger.WithFields(newEvent.logFields()).WithError(err).Warn("Error resolving owner")
+		}
+		kbEvent.Owner = owner
+	}
+	if c.rollouts != nil {
+		var ok bool
+		if kbEvent, ok = c.rollouts.Filter(newEvent, kbEvent); !ok {
+			c.logger.WithFields(newEvent.logFields()).Debug("Holding delete event for rollout detection")
+			return nil
+		}
+	}
+	return c.deliver(ctx, newEvent, kbEvent)
+}
+
+// Records, throttles and handles an event.
+func (c *Controller) deliver(ctx context.Context, newEvent event, kbEvent Event) error {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("status", kbEvent.Status))
+	kbEvent.ID = eventID(kbEvent, newEvent.eventType, newEvent.resourceVersion)
+	c.logger.WithFields(newEvent.logFields()).WithField("status", kbEvent.Status).Debug("Dispatching event")
+	countEvent(kbEvent, newEvent.eventType, c.settings().MetricsNamespaces)
+	if c.audit != nil {
+		if err := c.audit.Record(kbEvent); err != nil {
+			c.logger.WithFields(newEvent.logFields()).WithError(err).Error("Error writing audit record")
+		}
+	}
+	if !c.throttle.Allow(kbEvent) {
+		c.logger.WithFields(newEvent.logFields()).Debug("Alert throttled")
+		throttledTotal.Inc()
+		return nil
+	}
+	c.history.Add(kbEvent)
+	if err := c.handle(ctx, kbEvent); err != nil {
+		return &handlerError{delivery: kbEvent, err: err}
+	}
+	return nil
+}
+
+// Error of the event handler for a delivered event. The event is already
+// counted and recorded, so a retry only passes it to the handler again.
+type handlerError struct {
+	delivery Event
+	err      error
+}
+
+func (e *handlerError) Error() string {
+	return e.err.Error()
+}
+
+// Combines the errors of the events sent for one queued event. A single error
+// is returned as is, so if it is a handlerError only its event is retried.
+// Several fail the whole queued event, which is processed again.
+func joinErrors(errs ...error) error {
+	var failed []error
+	var messages []string
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+			messages = append(messages, err.Error())
+		}
+	}
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return failed[0]
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// Passes an event to the event handler.
+func (c *Controller) handle(ctx context.Context, kbEvent Event) error {
+	_, span := tracer().Start(ctx, "Handle")
+	defer span.End()
+	err := c.eventHandler.Handle(kbEvent)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return err
+}
+
+// Returns when the event happened. Core Events carry their own LastTimestamp;
+// for anything else the event happens now.
+func eventTimestamp(obj interface{}, now time.Time) time.Time {
+	if e, ok := obj.(*api_v1.Event); ok && !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	return now
+}
+
+// Returns the node a Pod runs on, or "" for anything else.
+func eventHost(obj interface{}) string {
+	if pod, ok := obj.(*api_v1.Pod); ok {
+		return pod.Spec.NodeName
+	}
+	return ""
+}
+
+// GetObjectMetaData returns metadata of a given k8s object
+func getObjectMetaData(obj interface{}) (objectMeta meta_v1.ObjectMeta) {
+
+	switch object := obj.(type) {
+	case *apps_v1.Deployment:
+		objectMeta = object.ObjectMeta
+	case *api_v1.ReplicationController:
+		objectMeta = object.ObjectMeta
+	case *apps_v1.ReplicaSet:
+		objectMeta = object.ObjectMeta
+	case *apps_v1.DaemonSet:
+		objectMeta = object.ObjectMeta
+	case *api_v1.Service:
+		objectMeta = object.ObjectMeta
+	case *api_v1.Pod:
+		objectMeta = object.ObjectMeta
+	case *batch_v1.Job:
+		objectMeta = object.ObjectMeta
+	case *api_v1.PersistentVolume:
+		objectMeta = object.ObjectMeta
+	case *api_v1.Namespace:
+		objectMeta = object.ObjectMeta
+	case *api_v1.Secret:
+		objectMeta = object.ObjectMeta
+	case *api_v1.ConfigMap:
+		objectMeta = object.ObjectMeta
+	case *ext_v1beta1.Ingress:
+		objectMeta = object.ObjectMeta
+	case *api_v1.Node:
+		objectMeta = object.ObjectMeta
+	case *rbac_v1beta1.ClusterRole:
+		objectMeta = object.ObjectMeta
+	case *api_v1.ServiceAccount:
+		objectMeta = object.ObjectMeta
+	case *api_v1.Event:
+		objectMeta = object.ObjectMeta
+	case nil:
+		// deleted objects are no longer in the store
+	default:
+		reportUnhandledObject(fmt.Sprintf("%T", obj))
+	}
+	return objectMeta
+}
@@ -0,0 +1,365 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Handler which fails every event while err is set.
+type failingHandler struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (h *failingHandler) Handle(e Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+func (h *failingHandler) SetErr(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.err = err
+}
+
+func TestHandlerErrorRequeuesUntilSuccess(t *testing.T) {
+	h := &failingHandler{err: errors.New("sink down")}
+	c := NewController(nil, h, Config{})
+	queue := NewFakeRateLimitingQueue()
+	c.queue = queue
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &api_v1.Pod{}, 0, cache.Indexers{})
+	c.AddInformer("Pod", informer)
+	informer.GetIndexer().Add(&api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "web-1", Namespace: "default", CreationTimestamp: meta_v1.Now()}})
+	item := event{key: "default/web-1", eventType: "create", resourceType: "Pod"}
+	key := item.queueKey()
+	c.enqueue(item)
+
+	c.processNextItem()
+
+	if len(queue.RateLimited) != 1 || queue.RateLimited[0] != key {
+		t.Errorf("Got AddRateLimited(%v), want one call for the failed event", queue.RateLimited)
+	}
+	if len(queue.Forgotten) != 0 {
+		t.Errorf("Got Forget(%v), want no calls while the handler fails", queue.Forgotten)
+	}
+
+	h.SetErr(nil)
+	queue.Add(key)
+	c.processNextItem()
+
+	if len(queue.RateLimited) != 1 {
+		t.Errorf("Got AddRateLimited(%v), want no retry after success", queue.RateLimited)
+	}
+	if len(queue.Forgotten) != 1 || queue.Forgotten[0] != key {
+		t.Errorf("Got Forget(%v), want the event forgotten after success", queue.Forgotten)
+	}
+}
+
+func TestCreatedDeploymentReachesHandler(t *testing.T) {
+	tc := newTestController(t, Config{})
+	tc.start()
+
+	if _, err := tc.client.AppsV1().Deployments("default").Create(context.TODO(), newDeployment("web"), meta_v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	tc.waitFor("the create to be queued", func() bool { return tc.queue.Len() > 0 })
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 1 {
+		t.Fatalf("Got %d events, want 1: %+v", len(events), events)
+	}
+	e := events[0]
+	if e.Kind != "Deployment" || e.Namespace != "default" || e.Name != "web" || e.Reason != "Created" || e.Status != "Normal" {
+		t.Errorf("Got %+v, want a Normal Created event for Deployment default/web", e)
+	}
+}
+
+func TestInjectedEventsReachHandler(t *testing.T) {
+	tc := newTestController(t, Config{})
+	pod := newPod("web-1")
+	tc.add("Pod", pod)
+	tc.drain()
+	tc.remove("Pod", pod)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 2 || events[0].Reason != "Created" || events[1].Reason != "Deleted" {
+		t.Fatalf("Got %+v, want Created then Deleted", events)
+	}
+}
+
+func TestHandlerErrorRequeuesOnce(t *testing.T) {
+	tc := newTestController(t, Config{})
+	queue := NewFakeRateLimitingQueue()
+	tc.queue = queue
+	tc.handler.SetErr(errors.New("sink down"))
+	tc.add("Pod", newPod("web-1"))
+
+	tc.processNextItem()
+
+	if len(queue.RateLimited) != 1 || queue.RateLimited[0] != "Pod/default/web-1" {
+		t.Errorf("Got AddRateLimited(%v), want one call for Pod/default/web-1", queue.RateLimited)
+	}
+	if len(queue.Forgotten) != 0 {
+		t.Errorf("Got Forget(%v), want no calls", queue.Forgotten)
+	}
+	if len(queue.Finished) != 1 {
+		t.Errorf("Got Done(%v), want one call", queue.Finished)
+	}
+}
+
+func TestHandlerSuccessForgets(t *testing.T) {
+	tc := newTestController(t, Config{})
+	queue := NewFakeRateLimitingQueue()
+	tc.queue = queue
+	tc.add("Pod", newPod("web-1"))
+
+	tc.processNextItem()
+
+	if len(queue.RateLimited) != 0 {
+		t.Errorf("Got AddRateLimited(%v), want no calls", queue.RateLimited)
+	}
+	if len(queue.Forgotten) != 1 || queue.Forgotten[0] != "Pod/default/web-1" {
+		t.Errorf("Got Forget(%v), want one call for Pod/default/web-1", queue.Forgotten)
+	}
+}
+
+func TestRetriesRecordEventOnce(t *testing.T) {
+	tc := newTestController(t, Config{})
+	queue := NewFakeRateLimitingQueue()
+	tc.queue = queue
+	audit, err := NewAuditSink(filepath.Join(t.TempDir(), "audit.log"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc.SetAuditSink(audit)
+	counter := eventsTotal.WithLabelValues("default", "Pod", "Normal", "create")
+	before := testutil.ToFloat64(counter)
+	tc.handler.SetErr(errors.New("sink down"))
+	tc.add("Pod", newPod("web-1"))
+
+	tc.processNextItem()
+	tc.queue.Add("Pod/default/web-1")
+	tc.processNextItem()
+	tc.handler.SetErr(nil)
+	tc.queue.Add("Pod/default/web-1")
+	tc.processNextItem()
+
+	if calls := tc.handler.Calls(); calls != 3 {
+		t.Errorf("Handler called %d times, want 3", calls)
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("Counted %v events, want 1", got)
+	}
+	if recent := tc.history.Recent(0, ""); len(recent) != 1 {
+		t.Errorf("Got %d history entries, want 1", len(recent))
+	}
+	audit.Close()
+	data, err := ioutil.ReadFile(audit.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Errorf("Got %d audit records, want 1", lines)
+	}
+	if len(queue.Forgotten) != 1 {
+		t.Errorf("Got Forget(%v), want one call once delivered", queue.Forgotten)
+	}
+}
+
+func TestEventsFromEveryInformerReachHandler(t *testing.T) {
+	tc := newTestController(t, Config{})
+	tc.start()
+
+	if _, err := tc.client.AppsV1().Deployments("default").Create(context.TODO(), newDeployment("web"), meta_v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.client.CoreV1().Pods("default").Create(context.TODO(), newPod("web-1"), meta_v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	tc.waitFor("both creates to be queued", func() bool { return tc.queue.Len() == 2 })
+	tc.drain()
+
+	kinds := map[string]string{}
+	for _, e := range tc.handler.Events() {
+		kinds[e.Kind] = e.Name
+	}
+	if len(kinds) != 2 || kinds["Deployment"] != "web" || kinds["Pod"] != "web-1" {
+		t.Errorf("Got events by kind %v, want Deployment web and Pod web-1", kinds)
+	}
+}
+
+func TestIsFreshCreate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		min, max, age time.Duration
+		fresh         bool
+	}{
+		{0, 0, time.Hour, true},
+		{0, time.Minute, 0, true},
+		{0, time.Minute, time.Minute, true},
+		{0, time.Minute, time.Minute + time.Nanosecond, false},
+		{10 * time.Second, time.Minute, 10*time.Second - time.Nanosecond, false},
+		{10 * time.Second, time.Minute, 10 * time.Second, true},
+		{10 * time.Second, 0, 24 * time.Hour, true},
+		{0, time.Minute, -time.Second, false},
+	}
+	for _, test := range tests {
+		c := NewController(nil, nil, Config{MinCreateAge: test.min, MaxCreateAge: test.max})
+		objectMeta := newObjectMeta("web-1")
+		objectMeta.CreationTimestamp.Time = now.Add(-test.age)
+		if got := c.isFreshCreate(objectMeta, now); got != test.fresh {
+			t.Errorf("Age %s with window [%s, %s] fresh %t, want %t", test.age, test.min, test.max, got, test.fresh)
+		}
+	}
+}
+
+func TestEventTimestamp(t *testing.T) {
+	before := time.Now()
+	tc := newTestController(t, Config{})
+	tc.add("Pod", newPod("web-1"))
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 1 {
+		t.Fatalf("Got %d events, want 1", len(events))
+	}
+	if ts := events[0].Timestamp; ts.Before(before) || ts.After(time.Now()) {
+		t.Errorf("Got timestamp %s, want the time of processing", ts)
+	}
+	b, err := json.Marshal(events[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]string
+	json.Unmarshal(b, &fields)
+	if _, err := time.Parse(time.RFC3339, fields["Timestamp"]); err != nil {
+		t.Errorf("Got Timestamp %q, want RFC3339: %v", fields["Timestamp"], err)
+	}
+}
+
+func TestCoreEventTimestamp(t *testing.T) {
+	last := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	ev := &api_v1.Event{ObjectMeta: newObjectMeta("web-1.abc"), LastTimestamp: meta_v1.NewTime(last)}
+	if got := eventTimestamp(ev, time.Now()); !got.Equal(last) {
+		t.Errorf("Got %s, want the Event's LastTimestamp %s", got, last)
+	}
+	now := time.Now()
+	if got := eventTimestamp(newPod("web-1"), now); !got.Equal(now) {
+		t.Errorf("Got %s for a Pod, want now", got)
+	}
+}
+
+func TestNilHandlerDiscardsEvents(t *testing.T) {
+	c := NewController(fake.NewSimpleClientset(), nil, Config{})
+	queue := NewFakeRateLimitingQueue()
+	c.queue = queue
+	c.AddInformer("Pod", cache.NewSharedIndexInformer(&cache.ListWatch{}, &api_v1.Pod{}, 0, cache.Indexers{}))
+	pod := newPod("web-1")
+	c.informerFor("Pod").GetIndexer().Add(pod)
+	c.informerHandler("Pod").OnAdd(pod)
+
+	if !c.processNextItem() {
+		t.Fatal("Queue shut down")
+	}
+	if len(queue.Forgotten) != 1 || len(queue.RateLimited) != 0 {
+		t.Errorf("Got Forget(%v) and AddRateLimited(%v), want the event processed once", queue.Forgotten, queue.RateLimited)
+	}
+}
+
+func TestDeleteCarriesLabels(t *testing.T) {
+	tc := newTestController(t, Config{})
+	pod := newPod("web-1")
+	pod.Labels = map[string]string{"app": "web", "team": "ops"}
+	tc.add("Pod", pod)
+	tc.drain()
+	tc.remove("Pod", pod)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 2 || events[1].Reason != "Deleted" {
+		t.Fatalf("Got %+v, want Created then Deleted", events)
+	}
+	if labels := events[1].Labels; labels["app"] != "web" || labels["team"] != "ops" {
+		t.Errorf("Delete carries labels %v, want those of the deleted pod", labels)
+	}
+}
+
+func TestClusterScopedKeys(t *testing.T) {
+	handler := &captureHandler{}
+	c := NewController(fake.NewSimpleClientset(), handler, Config{})
+	c.AddInformer("Node", cache.NewSharedIndexInformer(&cache.ListWatch{}, &api_v1.Node{}, 0, cache.Indexers{}))
+	c.AddInformer("Pod", cache.NewSharedIndexInformer(&cache.ListWatch{}, &api_v1.Pod{}, 0, cache.Indexers{}))
+	node := &api_v1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: "node-1", CreationTimestamp: meta_v1.Now()}}
+	for resourceType, obj := range map[string]interface{}{"Node": node, "Pod": newPod("web-1")} {
+		c.informerFor(resourceType).GetIndexer().Add(obj)
+		c.informerHandler(resourceType).OnAdd(obj)
+		c.processNextItem()
+	}
+
+	got := map[string]Event{}
+	for _, e := range handler.Events() {
+		got[e.Kind] = e
+	}
+	if e := got["Node"]; e.Namespace != "" || e.Name != "node-1" {
+		t.Errorf("Got Node event for %q/%q, want no namespace and name node-1", e.Namespace, e.Name)
+	}
+	if e := got["Pod"]; e.Namespace != "default" || e.Name != "web-1" {
+		t.Errorf("Got Pod event for %q/%q, want default/web-1", e.Namespace, e.Name)
+	}
+}
+
+func TestCreateOnDeletedKeyForgotten(t *testing.T) {
+	logs := test.NewGlobal()
+	tc := newTestController(t, Config{})
+	queue := NewFakeRateLimitingQueue()
+	tc.queue = queue
+	pod := newPod("web-1")
+	tc.add("Pod", pod)
+	// gone before the create is processed, with its delete already handled
+	tc.informerFor("Pod").GetIndexer().Delete(pod)
+
+	tc.processNextItem()
+
+	if len(queue.RateLimited) != 0 || len(queue.Forgotten) != 1 {
+		t.Errorf("Got AddRateLimited(%v) and Forget(%v), want the key forgotten", queue.RateLimited, queue.Forgotten)
+	}
+	if events := tc.handler.Events(); len(events) != 0 {
+		t.Errorf("Got %+v, want nothing reported", events)
+	}
+	for _, entry := range logs.AllEntries() {
+		if entry.Level <= log.WarnLevel {
+			t.Errorf("Logged %s %q, want no warnings or errors", entry.Level, entry.Message)
+		}
+	}
+}
+
+func TestCreateBeforePendingDeleteReported(t *testing.T) {
+	tc := newTestController(t, Config{})
+	pod := newPod("web-1")
+	tc.add("Pod", pod)
+	tc.remove("Pod", pod)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 2 || events[0].Reason != "Created" || events[1].Reason != "Deleted" {
+		t.Errorf("Got %+v, want Created then Deleted from the last known state", events)
+	}
+}
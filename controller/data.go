@@ -0,0 +1,88 @@
+package controller
+
+// Data change events for Secrets and ConfigMaps.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+// Returns the event kind and a reason naming the added, removed and modified
+// keys if old and new are Secrets or ConfigMaps whose data differs. Only key
+// names are reported, never values. isData is false for other objects.
+func dataChange(old, new interface{}) (kind, reason string, changed, isData bool) {
+	switch newObj := new.(type) {
+	case *api_v1.Secret:
+		oldObj, ok := old.(*api_v1.Secret)
+		if !ok {
+			return "", "", false, false
+		}
+		reason = keyChanges(secretData(oldObj), secretData(newObj))
+		return "SecretChanged", reason, reason != "", true
+	case *api_v1.ConfigMap:
+		oldObj, ok := old.(*api_v1.ConfigMap)
+		if !ok {
+			return "", "", false, false
+		}
+		reason = keyChanges(configMapData(oldObj), configMapData(newObj))
+		return "ConfigMapChanged", reason, reason != "", true
+	}
+	return "", "", false, false
+}
+
+// Secret values by key. StringData is write-only and normally empty in the
+// cache, but takes precedence over Data when set.
+func secretData(s *api_v1.Secret) map[string]string {
+	data := map[string]string{}
+	for k, v := range s.Data {
+		data[k] = string(v)
+	}
+	for k, v := range s.StringData {
+		data[k] = v
+	}
+	return data
+}
+
+// ConfigMap values by key, text and binary.
+func configMapData(cm *api_v1.ConfigMap) map[string]string {
+	data := map[string]string{}
+	for k, v := range cm.Data {
+		data[k] = v
+	}
+	for k, v := range cm.BinaryData {
+		data[k] = string(v)
+	}
+	return data
+}
+
+// Describes which keys were added, removed or modified, or "" if none.
+func keyChanges(old, new map[string]string) string {
+	var added, removed, modified []string
+	for k, v := range new {
+		oldValue, ok := old[k]
+		if !ok {
+			added = append(added, k)
+		} else if oldValue != v {
+			modified = append(modified, k)
+		}
+	}
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	var parts []string
+	for _, change := range []struct {
+		verb string
+		keys []string
+	}{{"added", added}, {"removed", removed}, {"modified", modified}} {
+		if len(change.keys) > 0 {
+			sort.Strings(change.keys)
+			parts = append(parts, fmt.Sprintf("%s %s", change.verb, strings.Join(change.keys, ",")))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+func newSecret(data map[string]string) *api_v1.Secret {
+	secret := &api_v1.Secret{ObjectMeta: newObjectMeta("db-credentials"), Data: map[string][]byte{}}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func TestSecretChangeListsKeysNotValues(t *testing.T) {
+	for name, config := range map[string]Config{
+		"all fields":     {},
+		"watched fields": {UpdateWatchFields: []string{"spec.replicas"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc := newTestController(t, config)
+			old := newSecret(map[string]string{"user": "admin", "password": "hunter2", "host": "db"})
+			tc.add("Secret", old)
+			tc.drain()
+			updated := newSecret(map[string]string{"user": "admin", "password": "correct-horse", "token": "s3cr3t"})
+			tc.update("Secret", old, updated)
+			tc.drain()
+
+			events := tc.handler.Events()
+			if len(events) != 2 || events[1].Kind != "SecretChanged" {
+				t.Fatalf("Got %+v, want Created and SecretChanged", events)
+			}
+			reason := events[1].Reason
+			if reason != "added token; removed host; modified password" {
+				t.Errorf("Got reason %q", reason)
+			}
+			for _, value := range []string{"admin", "hunter2", "correct-horse", "s3cr3t"} {
+				if strings.Contains(reason, value) {
+					t.Errorf("Reason %q contains the secret value %q", reason, value)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigMapWithoutDataChangeDoesNotAlert(t *testing.T) {
+	tc := newTestController(t, Config{})
+	old := &api_v1.ConfigMap{ObjectMeta: newObjectMeta("settings"), Data: map[string]string{"level": "debug"}}
+	tc.add("ConfigMap", old)
+	tc.drain()
+	relabelled := old.DeepCopy()
+	relabelled.Labels = map[string]string{"team": "web"}
+	tc.update("ConfigMap", old, relabelled)
+	tc.drain()
+
+	if events := tc.handler.Events(); len(events) != 1 {
+		t.Errorf("Got %+v, want only Created", events)
+	}
+}
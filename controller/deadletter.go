@@ -0,0 +1,109 @@
+package controller
+
+// Handling of events the controller gave up on.
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// GiveUpPolicy decides what happens to an event the controller gives up on.
+type GiveUpPolicy string
+
+const (
+	// GiveUpForget logs the event and drops it.
+	GiveUpForget GiveUpPolicy = "forget"
+	// GiveUpDeadLetter also passes it to the dead letter handler. This is the default.
+	GiveUpDeadLetter GiveUpPolicy = "dead-letter"
+	// GiveUpFatal dead-letters it and stops the controller, so an orchestrator
+	// can restart a process whose sink keeps failing.
+	GiveUpFatal GiveUpPolicy = "fatal"
+)
+
+// Checks the policy is known. The empty policy means GiveUpDeadLetter.
+func (p GiveUpPolicy) validate() error {
+	switch p {
+	case "", GiveUpForget, GiveUpDeadLetter, GiveUpFatal:
+		return nil
+	}
+	return fmt.Errorf("Unknown give up policy %q, expected forget, dead-letter or fatal", p)
+}
+
+// Time each queue key was first added, so retries can give up by age.
+type firstSeenTracker struct {
+	mu    sync.Mutex
+	times map[string]time.Time
+}
+
+func newFirstSeenTracker() *firstSeenTracker {
+	return &firstSeenTracker{times: map[string]time.Time{}}
+}
+
+// Records now as the first time key was seen, unless it is already tracked.
+func (t *firstSeenTracker) Add(key string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.times[key]; !ok {
+		t.times[key] = now
+	}
+}
+
+// Returns how long ago key was first seen, or 0 if it is not tracked.
+func (t *firstSeenTracker) Age(key string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	first, ok := t.times[key]
+	if !ok {
+		return 0
+	}
+	return now.Sub(first)
+}
+
+// Stops tracking key.
+func (t *firstSeenTracker) Forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.times, key)
+}
+
+// SetDeadLetterHandler sets a handler which receives a Danger event describing
+// every queued event the controller gives up on.
+func (c *Controller) SetDeadLetterHandler(h Handler) {
+	c.deadLetter = h
+}
+
+// Drops a failed event from the queue, applying the configured GiveUpPolicy.
+func (c *Controller) giveUp(key string, newEvent event, err error) {
+	c.queue.Forget(key)
+	c.firstSeen.Forget(key)
+	switch c.settings().GiveUpPolicy {
+	case GiveUpForget:
+		c.logger.WithFields(newEvent.logFields()).WithError(err).Error("Error processing event (giving up)")
+	case GiveUpFatal:
+		c.deadLetterEvent(newEvent, err)
+		c.abort(fmt.Errorf("Gave up on %s: %v", key, err))
+	default:
+		c.deadLetterEvent(newEvent, err)
+	}
+}
+
+// Logs a failed event and passes it to the dead letter handler, if there is one.
+func (c *Controller) deadLetterEvent(newEvent event, err error) {
+	c.logger.WithFields(newEvent.logFields()).WithError(err).Error("Error processing event (giving up)")
+	if c.deadLetter == nil {
+		return
+	}
+	fields := newEvent.logFields()
+	kbEvent := Event{
+		Namespace: fields["namespace"].(string),
+		Name:      fields["name"].(string),
+		Kind:      newEvent.resourceType,
+		Status:    "Danger",
+		Reason:    "GaveUp: " + err.Error(),
+		Timestamp: time.Now(),
+	}
+	if dlErr := c.deadLetter.Handle(kbEvent); dlErr != nil {
+		c.logger.WithFields(fields).WithError(dlErr).Error("Error dead-lettering event")
+	}
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Fails an event on its last retry under policy, returning the controller and
+// what was dead-lettered.
+func giveUpUnder(t *testing.T, policy GiveUpPolicy) (*testController, []Event) {
+	tc := newTestController(t, Config{GiveUpPolicy: policy})
+	queue := NewFakeRateLimitingQueue()
+	tc.queue = queue
+	deadLetter := &captureHandler{}
+	tc.SetDeadLetterHandler(deadLetter)
+	tc.handler.SetErr(errors.New("sink down"))
+	tc.add("Pod", newPod("web-1"))
+	queue.SetRequeues("Pod/default/web-1", maxRetries)
+
+	tc.processNextItem()
+
+	if len(queue.RateLimited) != 0 {
+		t.Errorf("Got AddRateLimited(%v), want no retry", queue.RateLimited)
+	}
+	if len(queue.Forgotten) != 1 {
+		t.Errorf("Got Forget(%v), want one call", queue.Forgotten)
+	}
+	return tc, deadLetter.Events()
+}
+
+func TestGiveUpDeadLetters(t *testing.T) {
+	_, events := giveUpUnder(t, GiveUpDeadLetter)
+	if len(events) != 1 {
+		t.Fatalf("Dead-lettered %+v, want one event", events)
+	}
+	e := events[0]
+	if e.Status != "Danger" || e.Kind != "Pod" || e.Name != "web-1" || !strings.HasPrefix(e.Reason, "GaveUp: ") {
+		t.Errorf("Got %+v, want a Danger GaveUp event for Pod web-1", e)
+	}
+}
+
+func TestGiveUpForgetDropsEvent(t *testing.T) {
+	if _, events := giveUpUnder(t, GiveUpForget); len(events) != 0 {
+		t.Errorf("Dead-lettered %+v, want nothing", events)
+	}
+}
+
+func TestGiveUpFatalStopsController(t *testing.T) {
+	tc, events := giveUpUnder(t, GiveUpFatal)
+	if len(events) != 1 {
+		t.Errorf("Dead-lettered %+v, want one event", events)
+	}
+	if !tc.aborted() {
+		t.Error("Controller not stopped")
+	}
+}
+
+func TestGiveUpByAge(t *testing.T) {
+	for _, age := range []time.Duration{30 * time.Second, 2 * time.Minute} {
+		tc := newTestController(t, Config{MaxItemAge: time.Minute})
+		queue := NewFakeRateLimitingQueue()
+		tc.queue = queue
+		deadLetter := &captureHandler{}
+		tc.SetDeadLetterHandler(deadLetter)
+		tc.handler.SetErr(errors.New("sink down"))
+		tc.add("Pod", newPod("web-1"))
+		tc.firstSeen.Forget("Pod/default/web-1")
+		tc.firstSeen.Add("Pod/default/web-1", time.Now().Add(-age))
+
+		tc.processNextItem()
+
+		events := deadLetter.Events()
+		if age < time.Minute {
+			if len(queue.RateLimited) != 1 || len(events) != 0 {
+				t.Errorf("Queued %s: got AddRateLimited(%v) and dead-lettered %+v, want a retry", age, queue.RateLimited, events)
+			}
+			continue
+		}
+		if len(queue.RateLimited) != 0 {
+			t.Errorf("Queued %s: got AddRateLimited(%v), want no retry", age, queue.RateLimited)
+		}
+		if len(events) != 1 || !strings.Contains(events[0].Reason, "Queued longer than 1m0s") {
+			t.Errorf("Queued %s: dead-lettered %+v, want one event given up by age", age, events)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package controller
+
+// Handler wrapper which logs events instead of delivering them.
+
+import (
+	"encoding/json"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DryRunHandler logs each event as JSON and never calls the wrapped handler.
+type DryRunHandler struct {
+	sink  string
+	inner Handler
+}
+
+// NewDryRunHandler returns a handler which stands in for inner, the sink
+// named sink. Wrap each sink separately, inside its severity filter, so only
+// the events it would receive are logged.
+func NewDryRunHandler(sink string, inner Handler) *DryRunHandler {
+	return &DryRunHandler{sink: sink, inner: inner}
+}
+
+// Handle logs the event at info level.
+func (d *DryRunHandler) Handle(e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	log.WithField("sink", d.sink).Infof("Dry run, not handling event: %s", b)
+	return nil
+}
+
+// HandleBatch logs each event and returns the result of each.
+func (d *DryRunHandler) HandleBatch(events []Event) []error {
+	errs := make([]error, len(events))
+	for i, e := range events {
+		errs[i] = d.Handle(e)
+	}
+	return errs
+}
+
+// Stop stops the inner handler.
+func (d *DryRunHandler) Stop() {
+	stopHandler(d.inner)
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestDryRunNeverCallsHandler(t *testing.T) {
+	logs := test.NewGlobal()
+	inner := &captureHandler{}
+	d := NewDryRunHandler("webhook", inner)
+	e := Event{Namespace: "default", Kind: "Pod", Name: "web-1", Reason: "Created"}
+
+	if err := d.Handle(e); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range d.HandleBatch([]Event{e, e}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if calls := inner.Calls(); calls != 0 {
+		t.Errorf("Handler called %d times, want never", calls)
+	}
+	if n := len(logs.AllEntries()); n != 3 {
+		t.Fatalf("Logged %d entries, want one per event", n)
+	}
+	if msg := logs.LastEntry().Message; !strings.Contains(msg, `"Name":"web-1"`) {
+		t.Errorf("Logged %q, want the event as JSON", msg)
+	}
+}
@@ -0,0 +1,134 @@
+package controller
+
+// Handler which emails events over SMTP.
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// DefaultSMTPTimeout bounds the exchange with the mail server for one email.
+const DefaultSMTPTimeout = 30 * time.Second
+
+// EmailHandler sends one email per event, or one per batch.
+type EmailHandler struct {
+	// SMTPAddr is the host:port of the mail server.
+	SMTPAddr string
+	From     string
+	To       []string
+	// Auth is optional.
+	Auth smtp.Auth
+	// StartTLS upgrades the connection before authenticating.
+	StartTLS bool
+	// Timeout bounds dialing and the whole exchange with the server, so one
+	// which stops answering can't stall a worker. Zero is DefaultSMTPTimeout.
+	Timeout time.Duration
+}
+
+// Handle emails the event.
+func (h *EmailHandler) Handle(e Event) error {
+	return h.send(h.message(emailSubject(e), e))
+}
+
+// HandleBatch emails the events as one digest.
+func (h *EmailHandler) HandleBatch(events []Event) error {
+	worst := "Normal"
+	for _, e := range events {
+		if severityRank[e.Status] > severityRank[worst] {
+			worst = e.Status
+		}
+	}
+	return h.send(h.message(fmt.Sprintf("[%s] %d Kubernetes events", worst, len(events)), events...))
+}
+
+// Sends an RFC 822 message to every recipient.
+func (h *EmailHandler) send(message []byte) error {
+	host, _, err := net.SplitHostPort(h.SMTPAddr)
+	if err != nil {
+		return err
+	}
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = DefaultSMTPTimeout
+	}
+	conn, err := net.DialTimeout("tcp", h.SMTPAddr, timeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if h.StartTLS {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if h.Auth != nil {
+		if err := c.Auth(h.Auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(h.From); err != nil {
+		return err
+	}
+	for _, to := range h.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Builds the RFC 822 message listing events, separated by blank lines.
+func (h *EmailHandler) message(subject string, events ...Event) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", h.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(h.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	for _, e := range events {
+		b.WriteString("\r\n")
+		writeEmailEvent(&b, e)
+	}
+	return b.Bytes()
+}
+
+// Writes the fields of an event, one per line.
+func writeEmailEvent(b *bytes.Buffer, e Event) {
+	fmt.Fprintf(b, "Namespace: %s\r\n", e.Namespace)
+	fmt.Fprintf(b, "Kind: %s\r\n", e.Kind)
+	fmt.Fprintf(b, "Name: %s\r\n", e.Name)
+	fmt.Fprintf(b, "Component: %s\r\n", e.Component)
+	fmt.Fprintf(b, "Host: %s\r\n", e.Host)
+	fmt.Fprintf(b, "Reason: %s\r\n", e.Reason)
+	fmt.Fprintf(b, "Status: %s\r\n", e.Status)
+	fmt.Fprintf(b, "Owner: %s\r\n", e.Owner)
+	fmt.Fprintf(b, "Timestamp: %s\r\n", e.Timestamp.Format(time.RFC3339))
+}
+
+// Subject line prefixed with the event severity, e.g. "[Danger] Deployment web-app Updated".
+func emailSubject(e Event) string {
+	return fmt.Sprintf("[%s] %s %s %s", e.Status, e.Kind, e.Name, e.Reason)
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// SMTP server accepting every message, recording recipients and bodies.
+type mockSMTP struct {
+	addr string
+
+	mu         sync.Mutex
+	recipients []string
+	messages   []string
+}
+
+func newMockSMTP(t *testing.T) *mockSMTP {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	s := &mockSMTP{addr: listener.Addr().String()}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *mockSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ready")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "RCPT":
+			s.mu.Lock()
+			s.recipients = append(s.recipients, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 Go ahead")
+			body, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, string(body))
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+func (s *mockSMTP) received() ([]string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.recipients...), append([]string(nil), s.messages...)
+}
+
+func TestEmailHandlerSendsEvent(t *testing.T) {
+	server := newMockSMTP(t)
+	h := &EmailHandler{SMTPAddr: server.addr, From: "controller@example.com", To: []string{"ops@example.com", "oncall@example.com"}}
+
+	if err := h.Handle(Event{Namespace: "default", Kind: "Pod", Name: "web-1", Status: "Danger", Reason: "Deleted"}); err != nil {
+		t.Fatal(err)
+	}
+
+	recipients, messages := server.received()
+	if len(recipients) != 2 || recipients[0] != "ops@example.com" || recipients[1] != "oncall@example.com" {
+		t.Errorf("Got recipients %q, want ops and oncall", recipients)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Got %d messages, want 1", len(messages))
+	}
+	if !strings.Contains(messages[0], "Subject: [Danger]") || !strings.Contains(messages[0], "web-1") {
+		t.Errorf("Got message %q, want a Danger subject naming web-1", messages[0])
+	}
+}
+
+func TestEmailHandlerSendsDigest(t *testing.T) {
+	server := newMockSMTP(t)
+	h := &EmailHandler{SMTPAddr: server.addr, From: "controller@example.com", To: []string{"ops@example.com"}}
+
+	err := h.HandleBatch([]Event{
+		{Namespace: "default", Kind: "Pod", Name: "web-1", Status: "Normal", Reason: "Created"},
+		{Namespace: "default", Kind: "Pod", Name: "web-2", Status: "Warning", Reason: "Updated"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, messages := server.received()
+	if len(messages) != 1 {
+		t.Fatalf("Got %d messages, want one digest", len(messages))
+	}
+	if !strings.Contains(messages[0], "Subject: [Warning] 2 Kubernetes events") {
+		t.Errorf("Got message %q, want a Warning digest subject", messages[0])
+	}
+}
+
+func TestEmailHandlerTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	done := make(chan struct{})
+	defer close(done)
+	// Accept the connection but never greet.
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		<-done
+	}()
+	h := &EmailHandler{SMTPAddr: listener.Addr().String(), From: "controller@example.com", To: []string{"ops@example.com"}, Timeout: 50 * time.Millisecond}
+
+	start := time.Now()
+	if err := h.Handle(Event{Kind: "Pod", Name: "web-1"}); err == nil {
+		t.Fatal("Got no error from a server which never answers")
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("Handle took %s, want it to give up after the 50ms timeout", took)
+	}
+}
@@ -0,0 +1,97 @@
+package controller
+
+// Alerts from core v1 Events, e.g. BackOff or NodeNotReady.
+
+import (
+	"context"
+	"time"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+// Status for well known Event reasons. Other reasons map from the Event's type.
+var coreEventStatus = map[string]string{
+	"BackOff":      "Danger",
+	"Failed":       "Danger",
+	"NodeNotReady": "Danger",
+	"Rebooted":     "Danger",
+	"NodeReady":    "Normal",
+}
+
+// Reports whether the Event's reason and type are in the configured allowlists.
+// An empty allowlist allows everything.
+func (c *Controller) coreEventWatched(ev *api_v1.Event) bool {
+	settings := c.settings()
+	return allowed(settings.WatchedReasons, ev.Reason) && allowed(settings.WatchedTypes, ev.Type)
+}
+
+// Reports whether value is in list, or list is empty.
+func allowed(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Turns a core Event into an Event about its involved object.
+func (c *Controller) processCoreEvent(ctx context.Context, newEvent event, ev *api_v1.Event) error {
+	if newEvent.eventType == "delete" || ev.Source.Component == EventSourceComponent || !c.coreEventWatched(ev) {
+		return nil
+	}
+	// don't replay Events which happened before startup
+	if newEvent.eventType == "create" && !newEvent.backfill && !c.isFreshCreate(ev.ObjectMeta, time.Now()) {
+		return nil
+	}
+	if ev.Reason == "BackOff" && !c.podBackOffAlertable(ev) {
+		return nil
+	}
+	status, ok := coreEventStatus[ev.Reason]
+	if !ok {
+		status = "Normal"
+		if ev.Type == api_v1.EventTypeWarning {
+			status = "Warning"
+		}
+	}
+	kbEvent := Event{
+		Name:      ev.InvolvedObject.Name,
+		Namespace: ev.InvolvedObject.Namespace,
+		Kind:      ev.InvolvedObject.Kind,
+		Component: ev.Source.Component,
+		Host:      ev.Source.Host,
+		Status:    status,
+		Reason:    ev.Reason,
+		Timestamp: eventTimestamp(ev, time.Now()),
+	}
+	if condition, ok := resolvingReasons[ev.Reason]; ok {
+		if active, err := c.resolveCondition(ctx, newEvent, kbEvent.Kind, kbEvent.Namespace, kbEvent.Name, condition, ev.Reason); active {
+			return err
+		}
+	}
+	if conditionReasons[ev.Reason] {
+		c.conditions.Fire(kbEvent, ev.Reason)
+	}
+	return c.dispatch(ctx, newEvent, kbEvent)
+}
+
+// Applies the crash loop thresholds to BackOff Events about Pods, when the
+// Pod is in the Pod informer's cache.
+func (c *Controller) podBackOffAlertable(ev *api_v1.Event) bool {
+	informer := c.informerFor("Pod")
+	if ev.InvolvedObject.Kind != "Pod" || informer == nil {
+		return true
+	}
+	obj, exists, err := informer.GetIndexer().GetByKey(ev.InvolvedObject.Namespace + "/" + ev.InvolvedObject.Name)
+	if err != nil || !exists {
+		return true
+	}
+	pod, ok := obj.(*api_v1.Pod)
+	if !ok {
+		return true
+	}
+	return c.crashLoopAlertable(pod, podRestarts(pod), time.Now())
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+// Returns a core Event of reason and type about the Pod named involved.
+func newCoreEvent(involved, reason, eventType string) *api_v1.Event {
+	return &api_v1.Event{
+		ObjectMeta:     newObjectMeta(involved + "." + reason),
+		InvolvedObject: api_v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: involved},
+		Reason:         reason,
+		Type:           eventType,
+	}
+}
+
+func TestWatchedReasons(t *testing.T) {
+	tc := newTestController(t, Config{WatchedReasons: []string{"Failed", "NodeNotReady"}})
+	for _, ev := range []*api_v1.Event{
+		newCoreEvent("web-1", "Scheduled", api_v1.EventTypeNormal),
+		newCoreEvent("web-2", "Failed", api_v1.EventTypeWarning),
+		newCoreEvent("web-3", "Pulled", api_v1.EventTypeNormal),
+		newCoreEvent("web-4", "NodeNotReady", api_v1.EventTypeWarning),
+		newCoreEvent("web-5", "FailedMount", api_v1.EventTypeWarning),
+	} {
+		tc.add("Event", ev)
+	}
+	tc.drain()
+
+	got := map[string]string{}
+	for _, e := range tc.handler.Events() {
+		got[e.Name] = e.Reason
+	}
+	if len(got) != 2 || got["web-2"] != "Failed" || got["web-4"] != "NodeNotReady" {
+		t.Errorf("Got reasons by object %v, want only Failed and NodeNotReady", got)
+	}
+}
+
+func TestWatchedTypes(t *testing.T) {
+	tc := newTestController(t, Config{WatchedTypes: []string{api_v1.EventTypeWarning}})
+	tc.add("Event", newCoreEvent("web-1", "Scheduled", api_v1.EventTypeNormal))
+	tc.add("Event", newCoreEvent("web-2", "FailedMount", api_v1.EventTypeWarning))
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 1 || events[0].Name != "web-2" || events[0].Status != "Warning" {
+		t.Errorf("Got %+v, want only the Warning for web-2", events)
+	}
+}
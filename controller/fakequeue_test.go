@@ -0,0 +1,113 @@
+package controller
+
+// In-memory workqueue recording how the controller uses it.
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// FakeRateLimitingQueue is a FIFO workqueue.RateLimitingInterface which
+// records every call. Rate limited and delayed items are only recorded, not
+// queued, so a test decides when a retry happens. Get never blocks: on an
+// empty queue it reports shutdown.
+type FakeRateLimitingQueue struct {
+	mu          sync.Mutex
+	items       []interface{}
+	shutDown    bool
+	Added       []interface{}
+	AddedAfter  []interface{}
+	RateLimited []interface{}
+	Forgotten   []interface{}
+	Finished    []interface{}
+	requeues    map[interface{}]int
+}
+
+var _ workqueue.RateLimitingInterface = &FakeRateLimitingQueue{}
+
+// NewFakeRateLimitingQueue returns an empty queue.
+func NewFakeRateLimitingQueue() *FakeRateLimitingQueue {
+	return &FakeRateLimitingQueue{requeues: map[interface{}]int{}}
+}
+
+func (q *FakeRateLimitingQueue) Add(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.Added = append(q.Added, item)
+	for _, queued := range q.items {
+		if queued == item {
+			return
+		}
+	}
+	q.items = append(q.items, item)
+}
+
+func (q *FakeRateLimitingQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+func (q *FakeRateLimitingQueue) Get() (interface{}, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return nil, true
+	}
+	item := q.items[0]
+	q.items = q.items[1:]
+	return item, false
+}
+
+func (q *FakeRateLimitingQueue) Done(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.Finished = append(q.Finished, item)
+}
+
+func (q *FakeRateLimitingQueue) ShutDown() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shutDown = true
+}
+
+func (q *FakeRateLimitingQueue) ShuttingDown() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.shutDown
+}
+
+func (q *FakeRateLimitingQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.AddedAfter = append(q.AddedAfter, item)
+}
+
+func (q *FakeRateLimitingQueue) AddRateLimited(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.RateLimited = append(q.RateLimited, item)
+	q.requeues[item]++
+}
+
+func (q *FakeRateLimitingQueue) Forget(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.Forgotten = append(q.Forgotten, item)
+	delete(q.requeues, item)
+}
+
+func (q *FakeRateLimitingQueue) NumRequeues(item interface{}) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.requeues[item]
+}
+
+// Sets the requeue count of item, e.g. to test running out of retries.
+func (q *FakeRateLimitingQueue) SetRequeues(item interface{}, n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.requeues[item] = n
+}
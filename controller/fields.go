@@ -0,0 +1,78 @@
+package controller
+
+// Comparison of selected fields between two versions of an object.
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Reports whether any of paths differs between old and new. Paths are dotted
+// field names as in the object's JSON, where a list segment may be suffixed with
+// [*] for every element or [N] for one element, e.g.
+// "spec.template.spec.containers[*].image". With no paths any change to spec
+// counts; objects without a spec always count as changed.
+func fieldsChanged(old, new interface{}, paths []string) bool {
+	oldFields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(old)
+	if err != nil {
+		return true
+	}
+	newFields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(new)
+	if err != nil {
+		return true
+	}
+	if len(paths) == 0 {
+		_, oldHasSpec := oldFields["spec"]
+		_, newHasSpec := newFields["spec"]
+		if !oldHasSpec && !newHasSpec {
+			return true
+		}
+		paths = []string{"spec"}
+	}
+	for _, path := range paths {
+		if !reflect.DeepEqual(fieldValues(oldFields, path), fieldValues(newFields, path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns every value found at path in obj.
+func fieldValues(obj map[string]interface{}, path string) []interface{} {
+	values := []interface{}{obj}
+	for _, segment := range strings.Split(path, ".") {
+		name, index := segment, ""
+		if i := strings.Index(segment, "["); i >= 0 && strings.HasSuffix(segment, "]") {
+			name, index = segment[:i], segment[i+1:len(segment)-1]
+		}
+		var next []interface{}
+		for _, v := range values {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			field, ok := m[name]
+			if !ok {
+				continue
+			}
+			if index == "" {
+				next = append(next, field)
+				continue
+			}
+			list, ok := field.([]interface{})
+			if !ok {
+				continue
+			}
+			if index == "*" {
+				next = append(next, list...)
+			} else if i, err := strconv.Atoi(index); err == nil && i >= 0 && i < len(list) {
+				next = append(next, list[i])
+			}
+		}
+		values = next
+	}
+	return values
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"testing"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+)
+
+// Returns a Deployment running image, scaled to replicas.
+func newDeploymentSpec(image string, replicas int32) *apps_v1.Deployment {
+	d := newDeployment("web")
+	d.Spec.Replicas = &replicas
+	d.Spec.Template.Spec.Containers = []api_v1.Container{{Name: "web", Image: image}}
+	return d
+}
+
+func TestFieldsChanged(t *testing.T) {
+	relabelled := newDeploymentSpec("web:1", 1)
+	relabelled.Labels = map[string]string{"team": "ops"}
+	tests := []struct {
+		name    string
+		new     *apps_v1.Deployment
+		paths   []string
+		changed bool
+	}{
+		{"new image, any spec field", newDeploymentSpec("web:2", 1), nil, true},
+		{"new image, watched image", newDeploymentSpec("web:2", 1), []string{"spec.template.spec.containers[*].image"}, true},
+		{"new image, watched first image", newDeploymentSpec("web:2", 1), []string{"spec.template.spec.containers[0].image"}, true},
+		{"new image, watched replicas", newDeploymentSpec("web:2", 1), []string{"spec.replicas"}, false},
+		{"scaled, watched replicas", newDeploymentSpec("web:1", 3), []string{"spec.replicas"}, true},
+		{"scaled, watched image", newDeploymentSpec("web:1", 3), []string{"spec.template.spec.containers[*].image"}, false},
+		{"relabelled, any spec field", relabelled, nil, false},
+		{"relabelled, watched labels", relabelled, []string{"metadata.labels"}, true},
+		{"unchanged", newDeploymentSpec("web:1", 1), []string{"spec.replicas", "metadata.labels"}, false},
+	}
+	for _, test := range tests {
+		if got := fieldsChanged(newDeploymentSpec("web:1", 1), test.new, test.paths); got != test.changed {
+			t.Errorf("%s: changed %t, want %t", test.name, got, test.changed)
+		}
+	}
+}
+
+func TestFieldsChangedWithoutSpec(t *testing.T) {
+	old, new := &api_v1.ConfigMap{ObjectMeta: newObjectMeta("settings")}, &api_v1.ConfigMap{ObjectMeta: newObjectMeta("settings")}
+	if !fieldsChanged(old, new, nil) {
+		t.Error("ConfigMap update unchanged, want every update to count")
+	}
+}
@@ -0,0 +1,38 @@
+package controller
+
+// Synthetic events proving the handler pipeline works.
+
+import (
+	"os"
+	"time"
+)
+
+// Kind of the synthetic heartbeat events.
+const heartbeatKind = "Heartbeat"
+
+// Sends a Heartbeat event to the handler every interval until stopCh closes,
+// so a downstream alert can fire when they stop arriving. Heartbeats skip
+// watch specs, throttling and history, and pass severity filters.
+func (c *Controller) heartbeat(interval time.Duration, stopCh <-chan struct{}) {
+	host, _ := os.Hostname()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case now := <-ticker.C:
+			kbEvent := Event{
+				Kind:      heartbeatKind,
+				Name:      "k8s-controller",
+				Host:      host,
+				Status:    "Normal",
+				Reason:    "Alive",
+				Timestamp: now,
+			}
+			if err := c.eventHandler.Handle(kbEvent); err != nil {
+				c.logger.WithError(err).Warn("Error sending heartbeat")
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeartbeats(t *testing.T) {
+	inner := &captureHandler{}
+	filter, err := NewSeverityFilterHandler("Danger", inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewController(nil, filter, Config{})
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		c.heartbeat(10*time.Millisecond, stopCh)
+		close(done)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for len(inner.Events()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(stopCh)
+	<-done
+
+	events := inner.Events()
+	if len(events) < 2 {
+		t.Fatalf("Got %d heartbeats, want at least 2", len(events))
+	}
+	for _, e := range events {
+		if e.Kind != heartbeatKind || e.Status != "Normal" || e.Reason != "Alive" {
+			t.Errorf("Got %+v, want a Normal Heartbeat", e)
+		}
+	}
+}
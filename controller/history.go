@@ -0,0 +1,76 @@
+package controller
+
+// Ring buffer of recently handled events, served as JSON.
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// DefaultEventHistorySize is how many events /events keeps unless configured.
+const DefaultEventHistorySize = 100
+
+// Fixed size ring buffer of Events.
+type eventHistory struct {
+	mu     sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+func newEventHistory(size int) *eventHistory {
+	if size <= 0 {
+		size = DefaultEventHistorySize
+	}
+	return &eventHistory{events: make([]Event, size)}
+}
+
+// Add records an event, overwriting the oldest once the buffer is full.
+func (h *eventHistory) Add(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events[h.next] = e
+	h.next = (h.next + 1) % len(h.events)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Recent returns up to limit events, newest first, optionally restricted to a namespace.
+// A limit of 0 or less returns every recorded event.
+func (h *eventHistory) Recent(limit int, namespace string) []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	count := h.next
+	if h.full {
+		count = len(h.events)
+	}
+	recent := []Event{}
+	for i := 1; i <= count; i++ {
+		if limit > 0 && len(recent) >= limit {
+			break
+		}
+		e := h.events[(h.next-i+len(h.events))%len(h.events)]
+		if namespace != "" && e.Namespace != namespace {
+			continue
+		}
+		recent = append(recent, e)
+	}
+	return recent
+}
+
+// ServeHTTP serves recent events as JSON. Supports ?limit= and ?namespace= parameters.
+func (h *eventHistory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil {
+			http.Error(w, "Invalid limit: "+l, http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Recent(limit, r.URL.Query().Get("namespace")))
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Gets /events with query from h, returning the event names.
+func getHistory(t *testing.T, h *eventHistory, query string) []string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/events"+query, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Got status %d for %s", w.Code, query)
+	}
+	var events []Event
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, e := range events {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+func TestHistoryNewestFirst(t *testing.T) {
+	h := newEventHistory(3)
+	for _, e := range []Event{
+		{Namespace: "default", Name: "a"},
+		{Namespace: "prod", Name: "b"},
+		{Namespace: "default", Name: "c"},
+		{Namespace: "prod", Name: "d"},
+	} {
+		h.Add(e)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"d", "c", "b"}},
+		{"?limit=2", []string{"d", "c"}},
+		{"?namespace=prod", []string{"d", "b"}},
+		{"?namespace=default&limit=1", []string{"c"}},
+		{"?namespace=staging", []string{}},
+	}
+	for _, test := range tests {
+		got := getHistory(t, h, test.query)
+		if len(got) != len(test.want) {
+			t.Errorf("%q got %v, want %v", test.query, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%q got %v, want %v", test.query, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestHistoryInvalidLimit(t *testing.T) {
+	w := httptest.NewRecorder()
+	newEventHistory(3).ServeHTTP(w, httptest.NewRequest("GET", "/events?limit=ten", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,18 @@
+package controller
+
+// Idempotency keys of events.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Derives an event's ID from what identifies the change it reports, so
+// retries of the same event get the same ID and other events a different one.
+// The reason tells apart events raised by one change, e.g. the Resolved and
+// Deleted events of a deleted pod.
+func eventID(e Event, eventType, resourceVersion string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{e.Namespace, e.Kind, e.Name, eventType, resourceVersion, e.Reason}, "/")))
+	return hex.EncodeToString(sum[:16])
+}
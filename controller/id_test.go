@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"testing"
+)
+
+func TestEventIDStableAcrossRetries(t *testing.T) {
+	e := Event{Namespace: "default", Kind: "Pod", Name: "web-0", Reason: "Created"}
+	retried := e
+	retried.Timestamp = retried.Timestamp.Add(1)
+	if eventID(e, "create", "42") != eventID(retried, "create", "42") {
+		t.Error("Got different IDs for a retried event")
+	}
+}
+
+func TestEventIDUniquePerEvent(t *testing.T) {
+	base := Event{Namespace: "default", Kind: "Pod", Name: "web-0", Reason: "Deleted"}
+	ids := map[string]string{"base": eventID(base, "delete", "42")}
+	add := func(what string, id string) {
+		for other, seen := range ids {
+			if seen == id {
+				t.Errorf("%s has the same ID as %s", what, other)
+			}
+		}
+		ids[what] = id
+	}
+	add("other version", eventID(base, "delete", "43"))
+	add("other event type", eventID(base, "update", "42"))
+	other := base
+	other.Name = "web-1"
+	add("other name", eventID(other, "delete", "42"))
+	resolved := base
+	resolved.Reason = "Resolved CrashLoopBackOff (firing since 2020-01-01T00:00:00Z): object deleted"
+	add("resolved event of the same delete", eventID(resolved, "delete", "42"))
+}
+
+func TestDeletedPodResolvedAndDeletedHaveDistinctIDs(t *testing.T) {
+	tc := newTestController(t, Config{})
+	pod := newPod("web-0")
+	tc.add("Pod", pod)
+	tc.drain()
+	tc.conditions.Fire(Event{Kind: "Pod", Namespace: "default", Name: "web-0", Status: "Danger"}, crashLoopBackOff)
+	tc.remove("Pod", pod)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 3 {
+		t.Fatalf("Got %+v, want Created, Resolved and Deleted", events)
+	}
+	if events[1].ID == events[2].ID {
+		t.Errorf("Resolved and Deleted events share ID %s", events[1].ID)
+	}
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIgnoreAnnotation(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		alerts      bool
+	}{
+		{nil, true},
+		{map[string]string{"k8s-controller/ignore": "true"}, false},
+		{map[string]string{"k8s-controller/ignore": "1"}, false},
+		{map[string]string{"k8s-controller/ignore": "false"}, true},
+		{map[string]string{"k8s-controller/ignore": "maybe"}, true},
+		{map[string]string{"other/ignore": "true"}, true},
+	}
+	for _, test := range tests {
+		tc := newTestController(t, Config{IgnoreAnnotation: "k8s-controller/ignore"})
+		pod := newPod("web-1")
+		pod.Annotations = test.annotations
+		tc.add("Pod", pod)
+		tc.drain()
+
+		if got := len(tc.handler.Events()) == 1; got != test.alerts {
+			t.Errorf("Annotations %v alerted %t, want %t", test.annotations, got, test.alerts)
+		}
+	}
+}
+
+func TestIgnoreManagedBy(t *testing.T) {
+	tc := newTestController(t, Config{IgnoreManagedBy: []string{"helm", "Job"}})
+	byHelm, byKubectl, byJob := newPod("web-1"), newPod("web-2"), newPod("migrate-1")
+	byHelm.ManagedFields = []meta_v1.ManagedFieldsEntry{{Manager: "helm", Operation: meta_v1.ManagedFieldsOperationApply}}
+	byKubectl.ManagedFields = []meta_v1.ManagedFieldsEntry{{Manager: "kubectl", Operation: meta_v1.ManagedFieldsOperationUpdate}}
+	byJob.OwnerReferences = []meta_v1.OwnerReference{{Kind: "Job", Name: "migrate"}}
+	for _, pod := range []*api_v1.Pod{byHelm, byKubectl, byJob} {
+		tc.add("Pod", pod)
+	}
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 1 || events[0].Name != "web-2" {
+		t.Errorf("Got %+v, want only the pod managed by kubectl", events)
+	}
+}
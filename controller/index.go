@@ -0,0 +1,39 @@
+package controller
+
+// Custom informer indexes and queue keys.
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ByOwnerIndex is the name of the index built by OwnerIndexFunc.
+const ByOwnerIndex = "byOwner"
+
+// OwnerIndexFunc indexes objects by the UIDs of their owners, so the children
+// of an object can be listed with informer.GetIndexer().ByIndex(ByOwnerIndex, uid).
+func OwnerIndexFunc(obj interface{}) ([]string, error) {
+	objectMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	var uids []string
+	for _, ref := range objectMeta.GetOwnerReferences() {
+		uids = append(uids, string(ref.UID))
+	}
+	return uids, nil
+}
+
+// Returns the store key of obj, and the key the queue deduplicates it by. The
+// latter is only set when a custom Config.KeyFunc is configured.
+func (c *Controller) objectKeys(obj interface{}) (string, string, error) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil || c.config.KeyFunc == nil {
+		return key, "", err
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	dedupKey, err := c.config.KeyFunc(obj)
+	return key, dedupKey, err
+}
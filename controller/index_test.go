@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Returns a Pod owned by the ReplicaSet with UID owner.
+func newPodOwnedBy(name string, owner types.UID) *api_v1.Pod {
+	pod := newPod(name)
+	pod.OwnerReferences = []meta_v1.OwnerReference{{Kind: "ReplicaSet", Name: "web-7d8f", UID: owner}}
+	return pod
+}
+
+func TestCustomIndexer(t *testing.T) {
+	tc := newTestController(t, Config{Indexers: cache.Indexers{ByOwnerIndex: OwnerIndexFunc}})
+	tc.add("Pod", newPodOwnedBy("web-1", "rs-a"))
+	tc.add("Pod", newPodOwnedBy("web-2", "rs-a"))
+	tc.add("Pod", newPodOwnedBy("api-1", "rs-b"))
+	tc.add("Pod", newPod("standalone"))
+
+	children, err := tc.informerFor("Pod").GetIndexer().ByIndex(ByOwnerIndex, "rs-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, obj := range children {
+		names[obj.(meta_v1.Object).GetName()] = true
+	}
+	if len(names) != 2 || !names["web-1"] || !names["web-2"] {
+		t.Errorf("Got children %v of rs-a, want web-1 and web-2", names)
+	}
+}
+
+func TestCustomKeyFunc(t *testing.T) {
+	byOwner := func(obj interface{}) (string, error) {
+		uids, err := OwnerIndexFunc(obj)
+		if err != nil || len(uids) == 0 {
+			return cache.MetaNamespaceKeyFunc(obj)
+		}
+		return uids[0], nil
+	}
+	tc := newTestController(t, Config{KeyFunc: byOwner})
+	tc.add("Pod", newPodOwnedBy("web-1", "rs-a"))
+	tc.add("Pod", newPodOwnedBy("web-2", "rs-a"))
+
+	if n := tc.queue.Len(); n != 1 {
+		t.Errorf("Got %d queued keys, want the pods of one owner queued under one key", n)
+	}
+	tc.drain()
+	if events := tc.handler.Events(); len(events) != 2 {
+		t.Errorf("Got %+v, want a create for each pod", events)
+	}
+}
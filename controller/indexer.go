@@ -0,0 +1,50 @@
+package controller
+
+// Access for handlers to the informer caches.
+
+import (
+	"k8s.io/client-go/tools/cache"
+)
+
+// Handler which looks up related objects, e.g. the Service in front of a failing
+// Pod, in the controller's informer caches instead of querying the apiserver.
+// SetIndexer is called once per informer as it is added, with the resource type
+// it watches, before any event is handled. The indexer must only be read. Reads
+// see the informer's cache, which can lag behind the apiserver.
+type indexerSetter interface {
+	SetIndexer(resourceType string, indexer cache.Indexer)
+}
+
+// Passes indexer to h if it is an indexerSetter.
+func setHandlerIndexer(h Handler, resourceType string, indexer cache.Indexer) {
+	if s, ok := h.(indexerSetter); ok {
+		s.SetIndexer(resourceType, indexer)
+	}
+}
+
+// SetIndexer passes the indexer to every handler.
+func (m *MultiHandler) SetIndexer(resourceType string, indexer cache.Indexer) {
+	for _, h := range m.handlers {
+		setHandlerIndexer(h, resourceType, indexer)
+	}
+}
+
+// SetIndexer passes the indexer to the inner handler.
+func (s *SeverityFilterHandler) SetIndexer(resourceType string, indexer cache.Indexer) {
+	setHandlerIndexer(s.inner, resourceType, indexer)
+}
+
+// SetIndexer passes the indexer to the inner handler.
+func (d *DryRunHandler) SetIndexer(resourceType string, indexer cache.Indexer) {
+	setHandlerIndexer(d.inner, resourceType, indexer)
+}
+
+// SetIndexer passes the indexer to the inner handler.
+func (b *BatchHandler) SetIndexer(resourceType string, indexer cache.Indexer) {
+	setHandlerIndexer(b.inner, resourceType, indexer)
+}
+
+// SetIndexer passes the indexer to the inner handler.
+func (b *CircuitBreakerHandler) SetIndexer(resourceType string, indexer cache.Indexer) {
+	setHandlerIndexer(b.inner, resourceType, indexer)
+}
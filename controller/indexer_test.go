@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"sync"
+	"testing"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Handler which looks up the Deployment named like the app label of each Pod
+// event in the informer cache.
+type siblingHandler struct {
+	mu          sync.Mutex
+	deployments cache.Indexer
+	found       []string
+}
+
+func (h *siblingHandler) SetIndexer(resourceType string, indexer cache.Indexer) {
+	if resourceType == "Deployment" {
+		h.deployments = indexer
+	}
+}
+
+func (h *siblingHandler) Handle(e Event) error {
+	if e.Kind != "Pod" {
+		return nil
+	}
+	obj, exists, err := h.deployments.GetByKey(e.Namespace + "/" + e.Labels["app"])
+	if err != nil || !exists {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.found = append(h.found, obj.(meta_v1.Object).GetName())
+	return nil
+}
+
+func TestHandlerResolvesSiblingFromIndexer(t *testing.T) {
+	h := &siblingHandler{}
+	c := NewController(fake.NewSimpleClientset(), h, Config{})
+	c.AddInformer("Deployment", cache.NewSharedIndexInformer(&cache.ListWatch{}, &apps_v1.Deployment{}, 0, cache.Indexers{}))
+	c.AddInformer("Pod", cache.NewSharedIndexInformer(&cache.ListWatch{}, &api_v1.Pod{}, 0, cache.Indexers{}))
+	deployment, pod := newDeployment("web"), newPod("web-1")
+	pod.Labels = map[string]string{"app": "web"}
+	c.informerFor("Deployment").GetIndexer().Add(deployment)
+	c.informerFor("Pod").GetIndexer().Add(pod)
+	c.informerHandler("Pod").OnAdd(pod)
+
+	c.processNextItem()
+
+	if len(h.found) != 1 || h.found[0] != "web" {
+		t.Errorf("Resolved %v, want Deployment web", h.found)
+	}
+}
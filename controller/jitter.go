@@ -0,0 +1,21 @@
+package controller
+
+// Random jitter on top of a workqueue rate limiter.
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// RateLimiter which adds up to maxFactor times each delay at random, so retries
+// of many items failing together spread out.
+type jitterRateLimiter struct {
+	workqueue.RateLimiter
+	maxFactor float64
+}
+
+func (r *jitterRateLimiter) When(item interface{}) time.Duration {
+	return wait.Jitter(r.RateLimiter.When(item), r.maxFactor)
+}
@@ -0,0 +1,64 @@
+package controller
+
+// Handler which produces events to a Kafka topic.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaProducer writes messages to Kafka. It is satisfied by *kafka.Writer.
+type KafkaProducer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// KafkaHandler produces each event as JSON to Topic, keyed by namespace/name
+// so the events of one object land in the same partition, in order.
+type KafkaHandler struct {
+	Topic    string
+	Producer KafkaProducer
+}
+
+// NewKafkaHandler returns a handler producing to topic through brokers.
+// Messages are partitioned by hashing their key. Handle waits for each message
+// to be acknowledged, so the writer sends batches after a few milliseconds
+// rather than the default second.
+func NewKafkaHandler(brokers []string, topic string) *KafkaHandler {
+	return &KafkaHandler{
+		Topic: topic,
+		Producer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 5 * time.Millisecond,
+		},
+	}
+}
+
+// Handle produces the event, returning an error if Kafka didn't accept it.
+func (k *KafkaHandler) Handle(e Event) error {
+	value, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	err = k.Producer.WriteMessages(context.Background(), kafka.Message{
+		Topic: k.Topic,
+		Key:   []byte(e.Namespace + "/" + e.Name),
+		Value: value,
+	})
+	if err != nil {
+		return fmt.Errorf("Error producing to Kafka topic %s: %v", k.Topic, err)
+	}
+	return nil
+}
+
+// Stop flushes and closes the producer if it can be closed.
+func (k *KafkaHandler) Stop() {
+	if closer, ok := k.Producer.(interface{ Close() error }); ok {
+		closer.Close()
+	}
+}
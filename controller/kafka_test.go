@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Producer which records messages instead of sending them.
+type recordingProducer struct {
+	messages []kafka.Message
+	err      error
+}
+
+func (p *recordingProducer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, msgs...)
+	return nil
+}
+
+func TestKafkaHandlerProducesKeyedEvent(t *testing.T) {
+	producer := &recordingProducer{}
+	k := &KafkaHandler{Topic: "alerts", Producer: producer}
+	e := Event{Namespace: "default", Kind: "Pod", Name: "web-1", Reason: "Created", Status: "Normal"}
+
+	if err := k.Handle(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(producer.messages) != 1 {
+		t.Fatalf("Got %d messages, want 1", len(producer.messages))
+	}
+	m := producer.messages[0]
+	if m.Topic != "alerts" || string(m.Key) != "default/web-1" {
+		t.Errorf("Got topic %q key %q, want alerts and default/web-1", m.Topic, m.Key)
+	}
+	var got Event
+	if err := json.Unmarshal(m.Value, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Namespace != e.Namespace || got.Name != e.Name || got.Reason != e.Reason || got.Status != e.Status {
+		t.Errorf("Got %+v, want %+v", got, e)
+	}
+}
+
+func TestKafkaHandlerReturnsProducerError(t *testing.T) {
+	k := &KafkaHandler{Topic: "alerts", Producer: &recordingProducer{err: errors.New("no leader")}}
+	if err := k.Handle(Event{Name: "web-1"}); err == nil {
+		t.Error("Got no error, want the producer's")
+	}
+}
+
+func TestNewKafkaHandlerBatchesBriefly(t *testing.T) {
+	w := NewKafkaHandler([]string{"localhost:9092"}, "alerts").Producer.(*kafka.Writer)
+	if w.BatchTimeout <= 0 || w.BatchTimeout > 10*time.Millisecond {
+		t.Errorf("Got batch timeout %s, want a few milliseconds", w.BatchTimeout)
+	}
+}
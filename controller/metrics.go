@@ -0,0 +1,66 @@
+package controller
+
+// Prometheus metrics exported by the controller.
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	circuitBreakerTripped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "circuit_breaker_tripped_total",
+		Help: "Number of events short-circuited by an open circuit breaker.",
+	})
+	throttledTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "throttled_total",
+		Help: "Number of alerts dropped by the per-object rate limit.",
+	})
+	watchErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "watch_errors_total",
+		Help: "Number of failed watch requests, by resource type.",
+	}, []string{"type"})
+	unhandledObjectTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "unhandled_object_total",
+		Help: "Number of objects whose metadata could not be read, by Go type.",
+	}, []string{"type"})
+	panicsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "panics_total",
+		Help: "Number of panics recovered while processing an event.",
+	})
+	ownerCacheHitsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "owner_cache_hits_total",
+		Help: "Number of owner lookups answered from the cache.",
+	})
+	ownerCacheMissesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "owner_cache_misses_total",
+		Help: "Number of owner lookups which had to query the apiserver.",
+	})
+	eventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_total",
+		Help: "Number of events produced, by namespace, kind, status and event type.",
+	}, []string{"namespace", "kind", "status", "event_type"})
+)
+
+// Namespace label for events outside the configured namespace allowlist.
+const otherNamespace = "other"
+
+func init() {
+	prometheus.MustRegister(circuitBreakerTripped)
+	prometheus.MustRegister(throttledTotal)
+	prometheus.MustRegister(watchErrorsTotal)
+	prometheus.MustRegister(unhandledObjectTotal)
+	prometheus.MustRegister(eventsTotal)
+	prometheus.MustRegister(panicsTotal)
+	prometheus.MustRegister(ownerCacheHitsTotal)
+	prometheus.MustRegister(ownerCacheMissesTotal)
+}
+
+// Counts an event. Namespaces missing from a non-empty allowlist are counted
+// as "other" to bound the label cardinality.
+func countEvent(kbEvent Event, eventType string, allowlist []string) {
+	namespace := kbEvent.Namespace
+	if !allowed(allowlist, namespace) {
+		namespace = otherNamespace
+	}
+	eventsTotal.WithLabelValues(namespace, kbEvent.Kind, kbEvent.Status, eventType).Inc()
+}
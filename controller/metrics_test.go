@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Returns the events_total count for the labels, to compare before and after.
+func eventCount(namespace, kind, status, eventType string) float64 {
+	return testutil.ToFloat64(eventsTotal.WithLabelValues(namespace, kind, status, eventType))
+}
+
+func TestEventsCountedByNamespace(t *testing.T) {
+	tc := newTestController(t, Config{})
+	prod, staging := newPod("web-1"), newPod("web-2")
+	prod.Namespace, staging.Namespace = "metrics-prod", "metrics-staging"
+	beforeProd := eventCount("metrics-prod", "Pod", "Normal", "create")
+	beforeStaging := eventCount("metrics-staging", "Pod", "Normal", "create")
+	tc.add("Pod", prod)
+	tc.add("Pod", staging)
+	tc.drain()
+
+	if got := eventCount("metrics-prod", "Pod", "Normal", "create") - beforeProd; got != 1 {
+		t.Errorf("Counted %v creates in metrics-prod, want 1", got)
+	}
+	if got := eventCount("metrics-staging", "Pod", "Normal", "create") - beforeStaging; got != 1 {
+		t.Errorf("Counted %v creates in metrics-staging, want 1", got)
+	}
+}
+
+func TestUnlistedNamespacesCountedAsOther(t *testing.T) {
+	tc := newTestController(t, Config{MetricsNamespaces: []string{"metrics-prod"}})
+	prod, staging := newPod("web-1"), newPod("web-2")
+	prod.Namespace, staging.Namespace = "metrics-prod", "metrics-staging"
+	beforeProd := eventCount("metrics-prod", "Pod", "Normal", "create")
+	beforeOther := eventCount(otherNamespace, "Pod", "Normal", "create")
+	beforeStaging := eventCount("metrics-staging", "Pod", "Normal", "create")
+	tc.add("Pod", prod)
+	tc.add("Pod", staging)
+	tc.drain()
+
+	if got := eventCount("metrics-prod", "Pod", "Normal", "create") - beforeProd; got != 1 {
+		t.Errorf("Counted %v creates in metrics-prod, want 1", got)
+	}
+	if got := eventCount(otherNamespace, "Pod", "Normal", "create") - beforeOther; got != 1 {
+		t.Errorf("Counted %v creates as other, want 1", got)
+	}
+	if got := eventCount("metrics-staging", "Pod", "Normal", "create") - beforeStaging; got != 0 {
+		t.Errorf("Counted %v creates in metrics-staging, want them counted as other", got)
+	}
+}
@@ -0,0 +1,146 @@
+package controller
+
+// Handler which fans events out to several handlers.
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long MultiHandler remembers which handlers took an event that failed
+// elsewhere. Retries come well within it; events given up on are forgotten.
+const partialDeliveryTTL = time.Hour
+
+// MultiHandler passes every event to each of its handlers. If some of them
+// fail, a retry of the event only goes to those, so the others don't receive
+// it twice.
+type MultiHandler struct {
+	handlers []Handler
+
+	mu sync.Mutex
+	// Which handlers took events retried after a partial failure, by event ID.
+	partial map[string]partialDelivery
+}
+
+type partialDelivery struct {
+	done  []bool
+	since time.Time
+}
+
+// NewMultiHandler returns a handler fanning events out to handlers.
+func NewMultiHandler(handlers ...Handler) *MultiHandler {
+	return &MultiHandler{
+		handlers: handlers,
+		partial:  map[string]partialDelivery{},
+	}
+}
+
+// Handle calls every handler which hasn't taken the event yet, even if an
+// earlier one fails, and returns their combined errors.
+func (m *MultiHandler) Handle(e Event) error {
+	done := m.takeDone(e.ID)
+	var errs []string
+	for i, h := range m.handlers {
+		if done[i] {
+			continue
+		}
+		if err := h.Handle(e); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		done[i] = true
+	}
+	if len(errs) > 0 {
+		m.keepDone(e.ID, done)
+		return fmt.Errorf("%d of %d handlers failed: %s", len(errs), len(m.handlers), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// HandleBatch passes the events to every handler which hasn't taken them yet,
+// as one batch if it takes batches. Each event fails with the combined errors
+// of the handlers which failed it.
+func (m *MultiHandler) HandleBatch(events []Event) []error {
+	done := make([][]bool, len(events))
+	for i, e := range events {
+		done[i] = m.takeDone(e.ID)
+	}
+	failures := make([][]string, len(events))
+	for h, handler := range m.handlers {
+		var batch []Event
+		var indexes []int
+		for i, e := range events {
+			if !done[i][h] {
+				batch = append(batch, e)
+				indexes = append(indexes, i)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		for j, err := range handleBatch(handler, batch) {
+			if err != nil {
+				failures[indexes[j]] = append(failures[indexes[j]], err.Error())
+				continue
+			}
+			done[indexes[j]][h] = true
+		}
+	}
+	errs := make([]error, len(events))
+	for i, e := range events {
+		if len(failures[i]) > 0 {
+			m.keepDone(e.ID, done[i])
+			errs[i] = fmt.Errorf("%d of %d handlers failed: %s", len(failures[i]), len(m.handlers), strings.Join(failures[i], "; "))
+		}
+	}
+	return errs
+}
+
+// Returns which handlers took the event with id, and forgets it.
+func (m *MultiHandler) takeDone(id string) []bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p, ok := m.partial[id]; ok && id != "" {
+		delete(m.partial, id)
+		return p.done
+	}
+	return make([]bool, len(m.handlers))
+}
+
+// Remembers which handlers took the event with id, for its retry. Events
+// without an ID can't be told apart, so they are retried everywhere.
+func (m *MultiHandler) keepDone(id string, done []bool) {
+	if id == "" {
+		return
+	}
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for other, p := range m.partial {
+		if now.Sub(p.since) > partialDeliveryTTL {
+			delete(m.partial, other)
+		}
+	}
+	m.partial[id] = partialDelivery{done: done, since: now}
+}
+
+// Stop stops every handler which needs to release resources on shutdown.
+func (m *MultiHandler) Stop() {
+	for _, h := range m.handlers {
+		stopHandler(h)
+	}
+}
+
+// Handler which needs to flush or release resources on shutdown.
+type stopper interface {
+	Stop()
+}
+
+// Stops h if it is a stopper.
+func stopHandler(h Handler) {
+	if s, ok := h.(stopper); ok {
+		s.Stop()
+	}
+}
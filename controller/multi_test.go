@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMultiHandlerRetriesOnlyFailedHandlers(t *testing.T) {
+	ok, flaky := &captureHandler{}, &captureHandler{}
+	m := NewMultiHandler(ok, flaky)
+	e := Event{ID: "id-1", Kind: "Pod", Name: "web-0"}
+
+	flaky.SetErr(errors.New("sink down"))
+	if err := m.Handle(e); err == nil {
+		t.Fatal("Got no error, want the flaky handler's")
+	}
+	flaky.SetErr(nil)
+	if err := m.Handle(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ok.Calls(); got != 1 {
+		t.Errorf("Working handler called %d times, want 1", got)
+	}
+	if got := len(flaky.Events()); got != 1 {
+		t.Errorf("Flaky handler took %d events, want 1", got)
+	}
+	if got := flaky.Calls(); got != 2 {
+		t.Errorf("Flaky handler called %d times, want 2", got)
+	}
+}
+
+func TestMultiHandlerDeliversNewEventsEverywhere(t *testing.T) {
+	a, b := &captureHandler{}, &captureHandler{}
+	m := NewMultiHandler(a, b)
+	for _, id := range []string{"id-1", "id-2"} {
+		if err := m.Handle(Event{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(a.Events()) != 2 || len(b.Events()) != 2 {
+		t.Errorf("Got %d and %d events, want 2 each", len(a.Events()), len(b.Events()))
+	}
+}
@@ -0,0 +1,74 @@
+package controller
+
+// Handler which publishes events to a NATS subject.
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher publishes a message to a subject. *nats.Conn is one; with
+// JetStream acks a jetStreamPublisher is used.
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// Publishes through JetStream, failing unless the stream acks within timeout.
+type jetStreamPublisher struct {
+	js      nats.JetStreamContext
+	timeout time.Duration
+}
+
+func (p jetStreamPublisher) Publish(subject string, data []byte) error {
+	_, err := p.js.Publish(subject, data, nats.AckWait(p.timeout))
+	return err
+}
+
+// NATSHandler publishes each event as JSON to Subject.
+type NATSHandler struct {
+	Subject   string
+	Publisher NATSPublisher
+	conn      *nats.Conn
+}
+
+// NewNATSHandler connects to the NATS server at url. With a non-zero
+// ackTimeout events are published through JetStream, and one the stream
+// doesn't ack in time fails, so it is retried.
+func NewNATSHandler(url, subject string, ackTimeout time.Duration) (*NATSHandler, error) {
+	conn, err := nats.Connect(url, nats.Name("k8s-controller"))
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to NATS: %v", err)
+	}
+	handler := &NATSHandler{Subject: subject, Publisher: conn, conn: conn}
+	if ackTimeout > 0 {
+		js, err := conn.JetStream()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("Error enabling JetStream: %v", err)
+		}
+		handler.Publisher = jetStreamPublisher{js: js, timeout: ackTimeout}
+	}
+	return handler, nil
+}
+
+// Handle publishes the event.
+func (n *NATSHandler) Handle(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := n.Publisher.Publish(n.Subject, data); err != nil {
+		return fmt.Errorf("Error publishing to NATS subject %s: %v", n.Subject, err)
+	}
+	return nil
+}
+
+// Stop flushes pending messages and closes the connection.
+func (n *NATSHandler) Stop() {
+	if n.conn != nil {
+		n.conn.Drain()
+	}
+}
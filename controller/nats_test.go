@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Publisher which records messages instead of sending them.
+type recordingPublisher struct {
+	subjects []string
+	messages [][]byte
+	err      error
+}
+
+func (p *recordingPublisher) Publish(subject string, data []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.subjects = append(p.subjects, subject)
+	p.messages = append(p.messages, data)
+	return nil
+}
+
+func TestNATSHandlerPublishesEvent(t *testing.T) {
+	publisher := &recordingPublisher{}
+	n := &NATSHandler{Subject: "k8s.events", Publisher: publisher}
+
+	if err := n.Handle(Event{Namespace: "default", Kind: "Pod", Name: "web-1", Reason: "Created"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(publisher.messages) != 1 || publisher.subjects[0] != "k8s.events" {
+		t.Fatalf("Published to %v, want one message on k8s.events", publisher.subjects)
+	}
+	var got Event
+	if err := json.Unmarshal(publisher.messages[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "web-1" || got.Reason != "Created" {
+		t.Errorf("Published %+v, want the event", got)
+	}
+}
+
+func TestNATSAckTimeoutRetried(t *testing.T) {
+	publisher := &recordingPublisher{err: nats.ErrTimeout}
+	tc := newTestController(t, Config{})
+	queue := NewFakeRateLimitingQueue()
+	tc.queue = queue
+	tc.eventHandler = &NATSHandler{Subject: "k8s.events", Publisher: publisher}
+	tc.add("Pod", newPod("web-1"))
+
+	tc.processNextItem()
+
+	if len(queue.RateLimited) != 1 {
+		t.Errorf("Got AddRateLimited(%v), want the unacked event retried", queue.RateLimited)
+	}
+	err := (&NATSHandler{Subject: "k8s.events", Publisher: publisher}).Handle(Event{})
+	if err == nil || !strings.Contains(err.Error(), nats.ErrTimeout.Error()) {
+		t.Errorf("Got %v, want the ack timeout", err)
+	}
+}
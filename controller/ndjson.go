@@ -0,0 +1,49 @@
+package controller
+
+// Handler which writes events as newline delimited JSON.
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// NDJSONHandler writes each event as one line of JSON, e.g. for log pipelines
+// tailing stdout.
+type NDJSONHandler struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewNDJSONHandler returns a handler writing to w, or to stdout if w is nil.
+func NewNDJSONHandler(w io.Writer) *NDJSONHandler {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &NDJSONHandler{w: w}
+}
+
+// Handle writes the event in a single write and flushes it, so lines from
+// concurrent workers never interleave and nothing is lost on a crash.
+func (n *NDJSONHandler) Handle(e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, err := n.w.Write(line); err != nil {
+		return err
+	}
+	switch w := n.w.(type) {
+	case interface{ Flush() error }:
+		return w.Flush()
+	case interface{ Sync() error }:
+		// Stdout may be a pipe, which can't be synced.
+		w.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// Writer which copies a byte at a time, yielding in between, so unsynchronized
+// concurrent writes would interleave.
+type slowWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		w.mu.Lock()
+		w.buf.WriteByte(b)
+		w.mu.Unlock()
+		runtime.Gosched()
+	}
+	return len(p), nil
+}
+
+func TestNDJSONConcurrentLines(t *testing.T) {
+	w := &slowWriter{}
+	h := NewNDJSONHandler(w)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h.Handle(Event{Kind: "Pod", Namespace: "default", Name: fmt.Sprintf("web-%d", i), Reason: "Created"})
+		}(i)
+	}
+	wg.Wait()
+
+	names := map[string]bool{}
+	scanner := bufio.NewScanner(&w.buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("Got interleaved line %q: %v", scanner.Text(), err)
+		}
+		names[e.Name] = true
+	}
+	if len(names) != 20 {
+		t.Errorf("Got %d distinct events, want 20", len(names))
+	}
+}
@@ -0,0 +1,131 @@
+package controller
+
+// Resolution of an object's top level owner, e.g. the Deployment behind a Pod.
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultOwnerCacheSize is the number of resolved owners kept by default.
+	DefaultOwnerCacheSize = 1024
+	// DefaultOwnerCacheTTL is how long a resolved owner is trusted by default.
+	DefaultOwnerCacheTTL = 10 * time.Minute
+)
+
+// A resolved owner, stored in the LRU list.
+type ownerEntry struct {
+	uid     types.UID
+	owner   string
+	expires time.Time
+}
+
+// Walks controller OwnerReferences, caching what each child resolves to in a
+// bounded LRU, so churning Pods don't each cost an API request.
+type ownerResolver struct {
+	clientset kubernetes.Interface
+	size      int
+	ttl       time.Duration
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[types.UID]*list.Element
+}
+
+// Zero size and ttl fall back to the defaults.
+func newOwnerResolver(clientset kubernetes.Interface, size int, ttl time.Duration) *ownerResolver {
+	if size <= 0 {
+		size = DefaultOwnerCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultOwnerCacheTTL
+	}
+	return &ownerResolver{
+		clientset: clientset,
+		size:      size,
+		ttl:       ttl,
+		lru:       list.New(),
+		entries:   map[types.UID]*list.Element{},
+	}
+}
+
+// Resolve returns the name of the object's top level controller, or "" if it has none.
+// Pods owned by a ReplicaSet resolve to the ReplicaSet's Deployment when there is one.
+func (r *ownerResolver) Resolve(ctx context.Context, objectMeta meta_v1.ObjectMeta) (string, error) {
+	ref := meta_v1.GetControllerOf(&objectMeta)
+	if ref == nil {
+		return "", nil
+	}
+	if ref.Kind != "ReplicaSet" {
+		return ref.Name, nil
+	}
+
+	if owner, ok := r.get(objectMeta.UID, time.Now()); ok {
+		ownerCacheHitsTotal.Inc()
+		return owner, nil
+	}
+	ownerCacheMissesTotal.Inc()
+
+	rs, err := r.clientset.AppsV1().ReplicaSets(objectMeta.Namespace).Get(ctx, ref.Name, meta_v1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	owner := rs.Name
+	if rsRef := meta_v1.GetControllerOf(rs); rsRef != nil && rsRef.Kind == "Deployment" {
+		owner = rsRef.Name
+	}
+	r.put(objectMeta.UID, owner, time.Now())
+	return owner, nil
+}
+
+// Returns the unexpired owner cached for uid, marking it recently used.
+func (r *ownerResolver) get(uid types.UID, now time.Time) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	element, ok := r.entries[uid]
+	if !ok {
+		return "", false
+	}
+	entry := element.Value.(*ownerEntry)
+	if now.After(entry.expires) {
+		r.remove(element)
+		return "", false
+	}
+	r.lru.MoveToFront(element)
+	return entry.owner, true
+}
+
+// Caches owner for uid, evicting the least recently used entry when full.
+func (r *ownerResolver) put(uid types.UID, owner string, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if element, ok := r.entries[uid]; ok {
+		r.remove(element)
+	}
+	r.entries[uid] = r.lru.PushFront(&ownerEntry{uid: uid, owner: owner, expires: now.Add(r.ttl)})
+	if r.lru.Len() > r.size {
+		r.remove(r.lru.Back())
+	}
+}
+
+// Forget drops the owner cached for a deleted object.
+func (r *ownerResolver) Forget(uid types.UID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if element, ok := r.entries[uid]; ok {
+		r.remove(element)
+	}
+}
+
+// Removes an entry. The caller holds mu.
+func (r *ownerResolver) remove(element *list.Element) {
+	r.lru.Remove(element)
+	delete(r.entries, element.Value.(*ownerEntry).uid)
+}
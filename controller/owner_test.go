@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Returns a ReplicaSet of Deployment web and a Pod of that ReplicaSet.
+func newOwnedPod() (*apps_v1.ReplicaSet, *api_v1.Pod) {
+	isController := true
+	rs := &apps_v1.ReplicaSet{ObjectMeta: newObjectMeta("web-7d8f")}
+	rs.OwnerReferences = []meta_v1.OwnerReference{{Kind: "Deployment", Name: "web", Controller: &isController}}
+	pod := newPod("web-7d8f-xyz")
+	pod.OwnerReferences = []meta_v1.OwnerReference{{Kind: "ReplicaSet", Name: rs.Name, Controller: &isController}}
+	return rs, pod
+}
+
+// Counts the ReplicaSet lookups made through the fake clientset.
+func (tc *testController) ownerLookups() int {
+	var n int
+	for _, action := range tc.client.Actions() {
+		if action.GetVerb() == "get" && action.GetResource().Resource == "replicasets" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPodEventsNameOwningDeployment(t *testing.T) {
+	rs, pod := newOwnedPod()
+	tc := newTestController(t, Config{}, rs)
+	tc.add("Pod", pod)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 1 || events[0].Owner != "web" {
+		t.Fatalf("Got %+v, want one event owned by web", events)
+	}
+	if n := tc.ownerLookups(); n != 1 {
+		t.Errorf("Looked up the ReplicaSet %d times, want 1", n)
+	}
+}
+
+func TestOwnerNotResolvedForSkippedEvents(t *testing.T) {
+	rs, pod := newOwnedPod()
+	pod.CreationTimestamp = meta_v1.NewTime(time.Now().Add(-time.Hour))
+	tc := newTestController(t, Config{MaxCreateAge: time.Minute}, rs)
+	tc.add("Pod", pod)
+	tc.drain()
+
+	if events := tc.handler.Events(); len(events) != 0 {
+		t.Fatalf("Got %+v, want no event for an old Pod", events)
+	}
+	if n := tc.ownerLookups(); n != 0 {
+		t.Errorf("Looked up the ReplicaSet %d times, want none", n)
+	}
+}
+
+func TestOwnerNotResolvedForFilteredEvents(t *testing.T) {
+	rs, pod := newOwnedPod()
+	tc := newTestController(t, Config{}, rs)
+	filter, err := NewSeverityFilterHandler("Danger", tc.handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc.eventHandler = filter
+	tc.add("Pod", pod)
+	tc.drain()
+
+	if events := tc.handler.Events(); len(events) != 0 {
+		t.Fatalf("Got %+v, want the Normal create filtered", events)
+	}
+	if n := tc.ownerLookups(); n != 0 {
+		t.Errorf("Looked up the ReplicaSet %d times, want none", n)
+	}
+}
+
+func TestOwnerCacheHit(t *testing.T) {
+	rs, pod := newOwnedPod()
+	tc := newTestController(t, Config{}, rs)
+	r := newOwnerResolver(tc.client, 0, 0)
+	hits := testutil.ToFloat64(ownerCacheHitsTotal)
+
+	for i := 0; i < 3; i++ {
+		if owner, err := r.Resolve(context.Background(), pod.ObjectMeta); err != nil || owner != "web" {
+			t.Fatalf("Resolved %q, %v, want web", owner, err)
+		}
+	}
+	if n := tc.ownerLookups(); n != 1 {
+		t.Errorf("Looked up the ReplicaSet %d times, want 1", n)
+	}
+	if got := testutil.ToFloat64(ownerCacheHitsTotal) - hits; got != 2 {
+		t.Errorf("Counted %v cache hits, want 2", got)
+	}
+}
+
+func TestOwnerCacheRefetchesAfterTTL(t *testing.T) {
+	rs, pod := newOwnedPod()
+	tc := newTestController(t, Config{}, rs)
+	r := newOwnerResolver(tc.client, 0, 10*time.Millisecond)
+
+	r.Resolve(context.Background(), pod.ObjectMeta)
+	time.Sleep(20 * time.Millisecond)
+	r.Resolve(context.Background(), pod.ObjectMeta)
+
+	if n := tc.ownerLookups(); n != 2 {
+		t.Errorf("Looked up the ReplicaSet %d times, want a refetch once expired", n)
+	}
+}
+
+func TestOwnerCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	rs, pod := newOwnedPod()
+	tc := newTestController(t, Config{}, rs)
+	r := newOwnerResolver(tc.client, 1, 0)
+	other := pod.DeepCopy()
+	other.Name, other.UID = "web-7d8f-abc", "uid-web-7d8f-abc"
+
+	r.Resolve(context.Background(), pod.ObjectMeta)
+	r.Resolve(context.Background(), other.ObjectMeta)
+	r.Resolve(context.Background(), pod.ObjectMeta)
+
+	if n := tc.ownerLookups(); n != 3 {
+		t.Errorf("Looked up the ReplicaSet %d times, want the evicted pod looked up again", n)
+	}
+}
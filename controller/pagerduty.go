@@ -0,0 +1,90 @@
+package controller
+
+// Handler which triggers PagerDuty incidents via the Events API v2.
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyHandler sends events to PagerDuty.
+type PagerDutyHandler struct {
+	RoutingKey string
+	// URL overrides the Events API endpoint.
+	URL    string
+	Client *http.Client
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string `json:"summary"`
+	Source        string `json:"source"`
+	Severity      string `json:"severity"`
+	Timestamp     string `json:"timestamp,omitempty"`
+	Component     string `json:"component,omitempty"`
+	Group         string `json:"group,omitempty"`
+	Class         string `json:"class,omitempty"`
+	CustomDetails Event  `json:"custom_details"`
+}
+
+// Handle triggers a PagerDuty event, or resolves the incident of an object if
+// the event resolves a condition.
+func (p *PagerDutyHandler) Handle(e Event) error {
+	url := p.URL
+	if url == "" {
+		url = pagerDutyEventsURL
+	}
+	return postJSON(p.Client, url, newPagerDutyEvent(p.RoutingKey, e))
+}
+
+func newPagerDutyEvent(routingKey string, e Event) pagerDutyEvent {
+	source := e.Host
+	if source == "" {
+		source = "k8s-controller"
+	}
+	return pagerDutyEvent{
+		RoutingKey:  routingKey,
+		EventAction: pagerDutyAction(e),
+		DedupKey:    fmt.Sprintf("%s/%s/%s", e.Namespace, e.Name, e.Kind),
+		Payload: pagerDutyPayload{
+			Summary:       fmt.Sprintf("%s %s/%s %s", e.Kind, e.Namespace, e.Name, e.Reason),
+			Source:        source,
+			Severity:      pagerDutySeverity(e.Status),
+			Timestamp:     e.Timestamp.Format(time.RFC3339),
+			Component:     e.Component,
+			Group:         e.Namespace,
+			Class:         e.Kind,
+			CustomDetails: e,
+		},
+	}
+}
+
+// Resolves the incident opened for a condition once it clears. Both share the
+// dedup key of the object.
+func pagerDutyAction(e Event) string {
+	if _, resolves := resolvedCondition(e); resolves {
+		return "resolve"
+	}
+	return "trigger"
+}
+
+// Maps an Event status to a PagerDuty severity.
+func pagerDutySeverity(status string) string {
+	switch status {
+	case "Danger":
+		return "critical"
+	case "Warning":
+		return "warning"
+	default:
+		return "info"
+	}
+}
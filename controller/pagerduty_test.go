@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPagerDutyTriggersAndResolves(t *testing.T) {
+	var mu sync.Mutex
+	var received []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pd pagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&pd); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		received = append(received, pd)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	p := &PagerDutyHandler{RoutingKey: "key", URL: server.URL}
+	fired := Event{Namespace: "default", Kind: "Pod", Name: "web-1", Status: "Danger", Reason: crashLoopBackOff, Timestamp: time.Now()}
+
+	if err := p.Handle(fired); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Handle(resolvedEvent(fired, crashLoopBackOff, "no container is crash looping")); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("Got %d PagerDuty events, want 2", len(received))
+	}
+	if received[0].EventAction != "trigger" || received[1].EventAction != "resolve" {
+		t.Errorf("Got actions %s then %s, want trigger then resolve", received[0].EventAction, received[1].EventAction)
+	}
+	if received[0].DedupKey != received[1].DedupKey {
+		t.Errorf("Got dedup keys %s and %s, want the same", received[0].DedupKey, received[1].DedupKey)
+	}
+	if received[0].Payload.Severity != "critical" || received[0].RoutingKey != "key" {
+		t.Errorf("Got %+v, want a critical event with the routing key", received[0])
+	}
+}
+
+func TestPagerDutyPayload(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		status, severity string
+	}{
+		{"Danger", "critical"},
+		{"Warning", "warning"},
+		{"Normal", "info"},
+		{"Unknown", "info"},
+	}
+	for _, test := range tests {
+		var got pagerDutyEvent
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Error(err)
+			}
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		p := &PagerDutyHandler{RoutingKey: "key", URL: server.URL}
+		err := p.Handle(Event{Namespace: "default", Kind: "Pod", Name: "web-1", Host: "node-1", Status: test.status, Reason: "Deleted", Timestamp: now})
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Payload.Severity != test.severity {
+			t.Errorf("Status %s got severity %s, want %s", test.status, got.Payload.Severity, test.severity)
+		}
+		if got.RoutingKey != "key" || got.EventAction != "trigger" || got.DedupKey != "default/web-1/Pod" {
+			t.Errorf("Got %+v, want a trigger for default/web-1/Pod with the routing key", got)
+		}
+		payload := got.Payload
+		if payload.Summary != "Pod default/web-1 Deleted" || payload.Source != "node-1" || payload.Timestamp != "2026-01-02T03:04:05Z" || payload.Group != "default" || payload.Class != "Pod" {
+			t.Errorf("Got payload %+v", payload)
+		}
+	}
+}
+
+func TestPagerDutyRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	p := &PagerDutyHandler{RoutingKey: "key", URL: server.URL}
+	if err := p.Handle(Event{Name: "web-1"}); err == nil {
+		t.Error("Got no error for a rejected event")
+	}
+}
+
+func TestPagerDutyResolvesThroughSeverityFilter(t *testing.T) {
+	var mu sync.Mutex
+	var actions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pd pagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&pd); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		actions = append(actions, pd.EventAction)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	tc := newTestController(t, Config{})
+	filter, err := NewSeverityFilterHandler("Danger", &PagerDutyHandler{RoutingKey: "key", URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc.eventHandler = filter
+
+	tc.add("Event", newNodeEvent("node-1", "NodeNotReady"))
+	tc.drain()
+	tc.add("Event", newNodeEvent("node-1", "NodeReady"))
+	tc.drain()
+	tc.add("Pod", newPod("web-1"))
+	tc.drain()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(actions) != 2 || actions[0] != "trigger" || actions[1] != "resolve" {
+		t.Errorf("Got actions %v, want trigger then resolve, and the Normal pod filtered", actions)
+	}
+}
@@ -0,0 +1,122 @@
+package controller
+
+// Pending event payloads per queue key.
+
+import (
+	"sync"
+)
+
+// The workqueue holds string keys so that repeated events for one object
+// collapse into a single queue item; the events for each key wait here, in
+// order, until a worker picks the key up. Consecutive updates coalesce, but
+// creates and deletes are kept, so none of them goes unreported.
+type pendingEvents struct {
+	mu     sync.Mutex
+	events map[string][]event
+}
+
+func newPendingEvents() *pendingEvents {
+	return &pendingEvents{events: map[string][]event{}}
+}
+
+// Queue key of an event: the object key qualified by its resource type, since
+// informers for different types can hold objects of the same name.
+func (e event) queueKey() string {
+	if e.dedupKey != "" {
+		return e.resourceType + "/" + e.dedupKey
+	}
+	return e.resourceType + "/" + e.key
+}
+
+// Replaces update with the later update next. The update then spans both,
+// from the state before the first to the state after the second.
+func coalesce(update, next event) event {
+	if update.key == next.key {
+		next.oldObj = update.oldObj
+	}
+	return next
+}
+
+// Appends e to the events of its key. An update following an update replaces
+// it instead, and so does a repeated create of the same object, e.g. by the
+// initial list and a backfill.
+func (p *pendingEvents) Set(e event) string {
+	key := e.queueKey()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	events := p.events[key]
+	if last := len(events) - 1; last >= 0 {
+		switch prev := events[last]; {
+		case prev.eventType == "update" && e.eventType == "update":
+			events[last] = coalesce(prev, e)
+			return key
+		case prev.eventType == "create" && e.eventType == "create" && prev.key == e.key:
+			e.backfill = e.backfill || prev.backfill
+			events[last] = e
+			return key
+		}
+	}
+	p.events[key] = append(events, e)
+	return key
+}
+
+// Puts back an event being retried, ahead of any which arrived meanwhile. If
+// the next one is an update too, they are merged.
+func (p *pendingEvents) Restore(e event) {
+	key := e.queueKey()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	events := p.events[key]
+	if len(events) > 0 && events[0].eventType == "update" && e.eventType == "update" {
+		events[0] = coalesce(e, events[0])
+		return
+	}
+	p.events[key] = append([]event{e}, events...)
+}
+
+// Removes and returns the oldest event for key.
+func (p *pendingEvents) Take(key string) (event, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	events := p.events[key]
+	if len(events) == 0 {
+		return event{}, false
+	}
+	if len(events) == 1 {
+		delete(p.events, key)
+	} else {
+		p.events[key] = events[1:]
+	}
+	return events[0], true
+}
+
+// Reports whether events for key are waiting.
+func (p *pendingEvents) Has(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.events[key]) > 0
+}
+
+// Reports whether a delete event for key is waiting.
+func (p *pendingEvents) Deleting(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range p.events[key] {
+		if e.eventType == "delete" {
+			return true
+		}
+	}
+	return false
+}
+
+// Removes and returns every pending event.
+func (p *pendingEvents) TakeAll() []event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var all []event
+	for _, events := range p.events {
+		all = append(all, events...)
+	}
+	p.events = map[string][]event{}
+	return all
+}
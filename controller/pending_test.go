@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+)
+
+func withReplicas(d *apps_v1.Deployment, replicas int32) *apps_v1.Deployment {
+	d = d.DeepCopy()
+	d.Spec.Replicas = &replicas
+	return d
+}
+
+func TestUpdatesCoalesceToLatestState(t *testing.T) {
+	p := newPendingEvents()
+	versions := []*apps_v1.Deployment{newDeployment("web")}
+	for i := int32(1); i <= 5; i++ {
+		versions = append(versions, withReplicas(versions[0], i))
+	}
+	var key string
+	for i := 1; i < len(versions); i++ {
+		key = p.Set(event{key: "default/web", eventType: "update", resourceType: "Deployment", oldObj: versions[i-1], lastObj: versions[i]})
+	}
+
+	e, ok := p.Take(key)
+	if !ok {
+		t.Fatal("No event pending")
+	}
+	if e.oldObj != versions[0] || e.lastObj != versions[len(versions)-1] {
+		t.Errorf("Got update from %v to %v, want from the first to the last state", e.oldObj, e.lastObj)
+	}
+	if _, ok := p.Take(key); ok {
+		t.Error("Got a second event, want the updates coalesced into one")
+	}
+}
+
+func TestCreatesAndDeletesAreKept(t *testing.T) {
+	for name, types := range map[string][]string{
+		"create then update": {"create", "update"},
+		"delete then create": {"delete", "create"},
+		"create then delete": {"create", "delete"},
+		"update then delete": {"update", "delete"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := newPendingEvents()
+			var key string
+			for _, eventType := range types {
+				key = p.Set(event{key: "default/web-0", eventType: eventType, resourceType: "Pod"})
+			}
+			for _, want := range types {
+				e, ok := p.Take(key)
+				if !ok || e.eventType != want {
+					t.Fatalf("Got %q, want %q", e.eventType, want)
+				}
+			}
+			if p.Has(key) {
+				t.Error("Events left over")
+			}
+		})
+	}
+}
+
+func TestRestoreKeepsFailedEvent(t *testing.T) {
+	p := newPendingEvents()
+	key := p.Set(event{key: "default/web-0", eventType: "create", resourceType: "Pod"})
+	failed, _ := p.Take(key)
+	p.Set(event{key: "default/web-0", eventType: "delete", resourceType: "Pod"})
+	p.Restore(failed)
+
+	if e, _ := p.Take(key); e.eventType != "create" {
+		t.Errorf("Got %q first, want the restored create", e.eventType)
+	}
+	if e, _ := p.Take(key); e.eventType != "delete" {
+		t.Errorf("Got %q second, want the delete", e.eventType)
+	}
+}
+
+func TestRestoreMergesUpdates(t *testing.T) {
+	p := newPendingEvents()
+	first, second, third := newDeployment("web"), withReplicas(newDeployment("web"), 2), withReplicas(newDeployment("web"), 3)
+	key := p.Set(event{key: "default/web", eventType: "update", resourceType: "Deployment", oldObj: first, lastObj: second})
+	failed, _ := p.Take(key)
+	p.Set(event{key: "default/web", eventType: "update", resourceType: "Deployment", oldObj: second, lastObj: third})
+	p.Restore(failed)
+
+	e, _ := p.Take(key)
+	if e.oldObj != first || e.lastObj != third {
+		t.Errorf("Got update from %v to %v, want from the failed update's old state to the latest", e.oldObj, e.lastObj)
+	}
+	if p.Has(key) {
+		t.Error("Got a second event, want the updates merged")
+	}
+}
+
+func TestBurstOfUpdatesHandledOnce(t *testing.T) {
+	tc := newTestController(t, Config{})
+	d := newDeployment("web")
+	tc.add("Deployment", d)
+	tc.drain()
+	old := d
+	for i := int32(1); i <= 50; i++ {
+		next := withReplicas(d, i)
+		tc.update("Deployment", old, next)
+		old = next
+	}
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 2 || events[1].Reason != "Updated" {
+		t.Errorf("Got %+v, want Created and one Updated", events)
+	}
+}
+
+func TestCreateFollowedByPhaseUpdateKeepsCreated(t *testing.T) {
+	tc := newTestController(t, Config{})
+	pod := newPod("web-0")
+	tc.add("Pod", pod)
+	running := pod.DeepCopy()
+	running.Spec.NodeName = "node-1"
+	running.Status.Phase = api_v1.PodRunning
+	tc.update("Pod", pod, running)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 2 || events[0].Reason != "Created" || events[1].Kind != "PodPhaseChange" {
+		t.Errorf("Got %+v, want Created then PodPhaseChange", events)
+	}
+}
+
+func TestDeleteAndRecreateKeepsBoth(t *testing.T) {
+	tc := newTestController(t, Config{})
+	pod := newPod("db-0")
+	tc.add("Pod", pod)
+	tc.drain()
+	tc.remove("Pod", pod)
+	recreated := newPod("db-0")
+	recreated.UID = "uid-db-0-2"
+	tc.add("Pod", recreated)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 3 || events[1].Reason != "Deleted" || events[2].Reason != "Created" {
+		t.Errorf("Got %+v, want Created, Deleted, Created", events)
+	}
+}
+
+func TestCreateThenDeleteKeepsBoth(t *testing.T) {
+	tc := newTestController(t, Config{})
+	pod := newPod("job-1")
+	tc.add("Pod", pod)
+	tc.remove("Pod", pod)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 2 || events[0].Reason != "Created" || events[1].Reason != "Deleted" {
+		t.Errorf("Got %+v, want Created then Deleted", events)
+	}
+}
+
+func TestFailedCreateRetriedBeforeLaterDelete(t *testing.T) {
+	tc := newTestController(t, Config{})
+	queue := NewFakeRateLimitingQueue()
+	tc.queue = queue
+	pod := newPod("job-1")
+	tc.handler.SetErr(errors.New("sink down"))
+	tc.add("Pod", pod)
+	tc.processNextItem()
+	tc.handler.SetErr(nil)
+	tc.remove("Pod", pod)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 2 || events[0].Reason != "Created" || events[1].Reason != "Deleted" {
+		t.Errorf("Got %+v, want the retried Created then Deleted", events)
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+// Pod phase transition and crash loop events.
+
+import (
+	"time"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+// Returns the old and new phase if old and new are Pods in different phases.
+func podPhaseChange(old, new interface{}) (from, to api_v1.PodPhase, changed bool) {
+	oldPod, ok := old.(*api_v1.Pod)
+	if !ok {
+		return "", "", false
+	}
+	newPod, ok := new.(*api_v1.Pod)
+	if !ok {
+		return "", "", false
+	}
+	if oldPod.Status.Phase == newPod.Status.Phase {
+		return "", "", false
+	}
+	return oldPod.Status.Phase, newPod.Status.Phase, true
+}
+
+// Maps the phase a Pod entered to an event status.
+func podPhaseStatus(phase api_v1.PodPhase) string {
+	switch phase {
+	case api_v1.PodFailed:
+		return "Danger"
+	case api_v1.PodRunning, api_v1.PodSucceeded:
+		return "Normal"
+	default:
+		return "Warning"
+	}
+}
+
+// Reason of a container waiting to be restarted after crashing.
+const crashLoopBackOff = "CrashLoopBackOff"
+
+// Returns a container of new which entered CrashLoopBackOff since old, with its
+// restart count, if old and new are Pods.
+func podCrashLoop(old, new interface{}) (container string, restarts int32, entered bool) {
+	oldPod, ok := old.(*api_v1.Pod)
+	if !ok {
+		return "", 0, false
+	}
+	newPod, ok := new.(*api_v1.Pod)
+	if !ok {
+		return "", 0, false
+	}
+	wasWaiting := map[string]bool{}
+	for _, status := range oldPod.Status.ContainerStatuses {
+		wasWaiting[status.Name] = status.State.Waiting != nil && status.State.Waiting.Reason == crashLoopBackOff
+	}
+	for _, status := range newPod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason == crashLoopBackOff && !wasWaiting[status.Name] {
+			return status.Name, status.RestartCount, true
+		}
+	}
+	return "", 0, false
+}
+
+// Reports whether old had a container in CrashLoopBackOff and new has none,
+// if both are Pods.
+func podCrashLoopCleared(old, new interface{}) bool {
+	oldPod, ok := old.(*api_v1.Pod)
+	if !ok {
+		return false
+	}
+	newPod, ok := new.(*api_v1.Pod)
+	if !ok {
+		return false
+	}
+	return crashLooping(oldPod) && !crashLooping(newPod)
+}
+
+// Reports whether any container of the pod is in CrashLoopBackOff.
+func crashLooping(pod *api_v1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason == crashLoopBackOff {
+			return true
+		}
+	}
+	return false
+}
+
+// Total restarts of the pod's containers.
+func podRestarts(pod *api_v1.Pod) int32 {
+	var restarts int32
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
+
+// Reports whether a crashing pod is old enough and restarted often enough to
+// alert, so single transient crashes and pods still starting up don't.
+func (c *Controller) crashLoopAlertable(pod *api_v1.Pod, restarts int32, now time.Time) bool {
+	settings := c.settings()
+	if int(restarts) <= settings.RestartThreshold {
+		return false
+	}
+	return now.Sub(pod.CreationTimestamp.Time) >= settings.MinObjectAge
+}
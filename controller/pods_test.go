@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Returns a copy of pod in phase.
+func inPhase(pod *api_v1.Pod, phase api_v1.PodPhase) *api_v1.Pod {
+	next := pod.DeepCopy()
+	next.Status.Phase = phase
+	return next
+}
+
+func TestPodPhaseTransitions(t *testing.T) {
+	tc := newTestController(t, Config{})
+	pending := inPhase(newPod("web-1"), api_v1.PodPending)
+	tc.add("Pod", pending)
+	tc.drain()
+	running := inPhase(pending, api_v1.PodRunning)
+	tc.update("Pod", pending, running)
+	tc.drain()
+	failed := inPhase(running, api_v1.PodFailed)
+	tc.update("Pod", running, failed)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 3 {
+		t.Fatalf("Got %+v, want a create and two phase changes", events)
+	}
+	for i, want := range []Event{
+		{Kind: "PodPhaseChange", Reason: "Pending→Running", Status: "Normal"},
+		{Kind: "PodPhaseChange", Reason: "Running→Failed", Status: "Danger"},
+	} {
+		e := events[i+1]
+		if e.Kind != want.Kind || e.Reason != want.Reason || e.Status != want.Status {
+			t.Errorf("Got %s %s %s, want %s %s %s", e.Kind, e.Reason, e.Status, want.Kind, want.Reason, want.Status)
+		}
+	}
+}
+
+func TestPodUpdateWithoutPhaseChange(t *testing.T) {
+	tc := newTestController(t, Config{})
+	running := inPhase(newPod("web-1"), api_v1.PodRunning)
+	tc.add("Pod", running)
+	tc.drain()
+	relabelled := running.DeepCopy()
+	relabelled.Labels = map[string]string{"team": "ops"}
+	tc.update("Pod", running, relabelled)
+	tc.drain()
+
+	if events := tc.handler.Events(); len(events) != 1 {
+		t.Errorf("Got %+v, want only the create", events)
+	}
+}
+
+// Returns a copy of pod whose container web is in CrashLoopBackOff after restarts.
+func inCrashLoop(pod *api_v1.Pod, restarts int32) *api_v1.Pod {
+	next := pod.DeepCopy()
+	next.Status.ContainerStatuses = []api_v1.ContainerStatus{{
+		Name:         "web",
+		RestartCount: restarts,
+		State:        api_v1.ContainerState{Waiting: &api_v1.ContainerStateWaiting{Reason: crashLoopBackOff}},
+	}}
+	return next
+}
+
+func TestCrashLoopThresholds(t *testing.T) {
+	tests := []struct {
+		name     string
+		restarts int32
+		age      time.Duration
+		alerts   bool
+	}{
+		{"below restart threshold", 3, time.Hour, false},
+		{"above restart threshold", 4, time.Hour, true},
+		{"younger than min age", 10, time.Minute, false},
+		{"at min age", 10, 5 * time.Minute, true},
+	}
+	for _, test := range tests {
+		tc := newTestController(t, Config{RestartThreshold: 3, MinObjectAge: 5 * time.Minute})
+		running := inPhase(newPod("web-1"), api_v1.PodRunning)
+		running.CreationTimestamp = meta_v1.NewTime(time.Now().Add(-test.age))
+		tc.add("Pod", running)
+		tc.drain()
+		tc.update("Pod", running, inCrashLoop(running, test.restarts))
+		tc.drain()
+
+		var alerted bool
+		for _, e := range tc.handler.Events() {
+			alerted = alerted || strings.HasPrefix(e.Reason, crashLoopBackOff)
+		}
+		if alerted != test.alerts {
+			t.Errorf("%s: alerted %t, want %t", test.name, alerted, test.alerts)
+		}
+	}
+}
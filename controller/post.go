@@ -0,0 +1,51 @@
+package controller
+
+// Shared helpers for HTTP based handlers.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultHTTPTimeout bounds each request of an HTTP based handler, so an
+// endpoint which never answers can't stall a worker.
+const DefaultHTTPTimeout = 10 * time.Second
+
+// Client of HTTP based handlers given none.
+var defaultClient = &http.Client{Timeout: DefaultHTTPTimeout}
+
+// POSTs payload as JSON to url. Any non-2xx response is returned as an error.
+func postJSON(client *http.Client, url string, payload interface{}) error {
+	return postJSONWithHeaders(client, url, payload, nil)
+}
+
+// POSTs payload as JSON to url with extra request headers.
+func postJSONWithHeaders(client *http.Client, url string, payload interface{}, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		client = defaultClient
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("POST %s returned %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package controller
+
+// Startup check that the controller is allowed to watch its resources.
+
+import (
+	"context"
+	"fmt"
+
+	authorization_v1 "k8s.io/api/authorization/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CheckAccess asks the apiserver whether the controller's own identity may list
+// and watch resource in API group in namespace (empty for all namespaces). It
+// returns an error naming the missing permission rather than letting the
+// informer fail silently later.
+func CheckAccess(ctx context.Context, clientset kubernetes.Interface, namespace, group, resource string) error {
+	for _, verb := range []string{"list", "watch"} {
+		review := &authorization_v1.SelfSubjectAccessReview{
+			Spec: authorization_v1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorization_v1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      verb,
+					Group:     group,
+					Resource:  resource,
+				},
+			},
+		}
+		result, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, meta_v1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("Error checking %s permission on %s: %v", verb, resource, err)
+		}
+		if !result.Status.Allowed {
+			scope := "all namespaces"
+			if namespace != "" {
+				scope = "namespace " + namespace
+			}
+			return fmt.Errorf("Not allowed to %s %s in %s, check the controller's RBAC: %s", verb, resource, scope, result.Status.Reason)
+		}
+	}
+	return nil
+}
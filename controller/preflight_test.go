@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	authorization_v1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// Returns a clientset whose access reviews allow the verbs in allowed.
+func accessClient(allowed ...string) (*fake.Clientset, *[]authorization_v1.ResourceAttributes) {
+	client := fake.NewSimpleClientset()
+	var reviewed []authorization_v1.ResourceAttributes
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorization_v1.SelfSubjectAccessReview)
+		attributes := *review.Spec.ResourceAttributes
+		reviewed = append(reviewed, attributes)
+		for _, verb := range allowed {
+			if verb == attributes.Verb {
+				review.Status.Allowed = true
+			}
+		}
+		if !review.Status.Allowed {
+			review.Status.Reason = "no RBAC policy matched"
+		}
+		return true, review, nil
+	})
+	return client, &reviewed
+}
+
+func TestCheckAccessAllowed(t *testing.T) {
+	client, reviewed := accessClient("list", "watch")
+	if err := CheckAccess(context.Background(), client, "prod", "apps", "deployments"); err != nil {
+		t.Fatal(err)
+	}
+	if len(*reviewed) != 2 {
+		t.Fatalf("Reviewed %+v, want list and watch", *reviewed)
+	}
+	for _, attributes := range *reviewed {
+		if attributes.Namespace != "prod" || attributes.Group != "apps" || attributes.Resource != "deployments" {
+			t.Errorf("Reviewed %+v, want deployments.apps in prod", attributes)
+		}
+	}
+}
+
+func TestCheckAccessDenied(t *testing.T) {
+	client, _ := accessClient("list")
+	err := CheckAccess(context.Background(), client, "", "", "pods")
+	if err == nil {
+		t.Fatal("Got no error without watch permission")
+	}
+	for _, want := range []string{"watch pods", "all namespaces", "no RBAC policy matched"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Got %q, want it to mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package controller
+
+// Workqueue rate limiter built from the configured backoff settings.
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Defaults match workqueue.DefaultControllerRateLimiter.
+const (
+	DefaultRetryBaseDelay = 5 * time.Millisecond
+	DefaultRetryMaxDelay  = 1000 * time.Second
+	DefaultQueueQPS       = 10
+	DefaultQueueBurst     = 100
+)
+
+// Combines per-item exponential backoff with an overall token bucket, using the
+// larger of the two delays. Zero config values fall back to the defaults.
+func newRateLimiter(config Config) workqueue.RateLimiter {
+	baseDelay := config.RetryBaseDelay
+	if baseDelay == 0 {
+		baseDelay = DefaultRetryBaseDelay
+	}
+	maxDelay := config.RetryMaxDelay
+	if maxDelay == 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+	qps := config.QueueQPS
+	if qps == 0 {
+		qps = DefaultQueueQPS
+	}
+	burst := config.QueueBurst
+	if burst == 0 {
+		burst = DefaultQueueBurst
+	}
+	limiter := workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+	)
+	if config.RetryJitter > 0 {
+		return &jitterRateLimiter{RateLimiter: limiter, maxFactor: config.RetryJitter}
+	}
+	return limiter
+}
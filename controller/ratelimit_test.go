@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBackoff(t *testing.T) {
+	limiter := newRateLimiter(Config{RetryBaseDelay: 10 * time.Millisecond, RetryMaxDelay: 50 * time.Millisecond})
+
+	for i, want := range []time.Duration{10, 20, 40, 50, 50} {
+		if got := limiter.When("Pod/default/web-1"); got != want*time.Millisecond {
+			t.Errorf("Requeue %d got %s, want %s", i+1, got, want*time.Millisecond)
+		}
+	}
+	if got := limiter.When("Pod/default/web-2"); got != 10*time.Millisecond {
+		t.Errorf("Other item got %s, want its own backoff of 10ms", got)
+	}
+	limiter.Forget("Pod/default/web-1")
+	if got := limiter.When("Pod/default/web-1"); got != 10*time.Millisecond {
+		t.Errorf("Forgotten item got %s, want the base delay", got)
+	}
+}
+
+func TestRateLimiterDefaults(t *testing.T) {
+	limiter := newRateLimiter(Config{})
+	for i, want := range []time.Duration{5, 10, 20} {
+		if got := limiter.When("Pod/default/web-1"); got != want*time.Millisecond {
+			t.Errorf("Requeue %d got %s, want %s", i+1, got, want*time.Millisecond)
+		}
+	}
+}
+
+func TestRateLimiterJitter(t *testing.T) {
+	limiter := newRateLimiter(Config{RetryBaseDelay: 100 * time.Millisecond, RetryMaxDelay: time.Second, RetryJitter: 0.5})
+	for i, base := range []time.Duration{100, 200, 400} {
+		base *= time.Millisecond
+		if got := limiter.When("Pod/default/web-1"); got < base || got > base+base/2 {
+			t.Errorf("Requeue %d got %s, want between %s and %s", i+1, got, base, base+base/2)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package controller
+
+// Readiness served at /readyz.
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type informerSync struct {
+	Synced bool `json:"synced"`
+	// Objects is the number of objects in the informer's cache so far.
+	Objects int `json:"objects"`
+}
+
+type readyResponse struct {
+	Ready     bool                    `json:"ready"`
+	Informers map[string]informerSync `json:"informers"`
+}
+
+// Serves the sync progress of every informer as JSON, with status 503 until
+// all of them synced. A growing object count tells a large initial list apart
+// from a stuck one.
+func (c *Controller) serveReady(w http.ResponseWriter, r *http.Request) {
+	response := readyResponse{Ready: true, Informers: map[string]informerSync{}}
+	for _, ri := range c.informers {
+		synced := ri.informer.HasSynced()
+		response.Informers[ri.resourceType] = informerSync{
+			Synced:  synced,
+			Objects: len(ri.informer.GetStore().ListKeys()),
+		}
+		response.Ready = response.Ready && synced
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !response.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
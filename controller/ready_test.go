@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Requests /readyz, returning the status code and response.
+func (tc *testController) ready() (int, readyResponse) {
+	tc.t.Helper()
+	recorder := httptest.NewRecorder()
+	tc.serveReady(recorder, httptest.NewRequest("GET", "/readyz", nil))
+	var response readyResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		tc.t.Fatal(err)
+	}
+	return recorder.Code, response
+}
+
+func TestReadyBeforeSync(t *testing.T) {
+	tc := newTestController(t, Config{})
+	tc.add("Pod", newPod("web-1"))
+
+	code, response := tc.ready()
+	if code != http.StatusServiceUnavailable || response.Ready {
+		t.Errorf("Got %d ready %t before sync, want 503 not ready", code, response.Ready)
+	}
+	if pods := response.Informers["Pod"]; pods.Synced || pods.Objects != 1 {
+		t.Errorf("Got Pod informer %+v, want 1 object, not synced", pods)
+	}
+}
+
+func TestReadyAfterSync(t *testing.T) {
+	tc := newTestController(t, Config{}, newPod("web-1"), newPod("web-2"), newDeployment("web"))
+	tc.start()
+
+	code, response := tc.ready()
+	if code != http.StatusOK || !response.Ready {
+		t.Errorf("Got %d ready %t after sync, want 200 ready", code, response.Ready)
+	}
+	for resourceType, want := range map[string]int{"Pod": 2, "Deployment": 1, "Secret": 0} {
+		if got := response.Informers[resourceType]; !got.Synced || got.Objects != want {
+			t.Errorf("Got %s informer %+v, want %d objects, synced", resourceType, got, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+// Handler which records events as Kubernetes Events on the involved object.
+
+import (
+	"fmt"
+	"strings"
+
+	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typed_core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// EventSourceComponent is the source of Events recorded by the controller.
+// Core Events from it are never alerted on, so recorded alerts don't loop.
+const EventSourceComponent = "k8s-controller"
+
+// Kind of the object an event is about, for event kinds which aren't
+// resources themselves.
+var involvedKinds = map[string]string{
+	"PodPhaseChange":   "Pod",
+	"SecretChanged":    "Secret",
+	"ConfigMapChanged": "ConfigMap",
+	"RolloutProgress":  "Deployment",
+}
+
+// Resources outside the core group which events can be about.
+var apiVersions = map[string]string{
+	"Deployment":  "apps/v1",
+	"ReplicaSet":  "apps/v1",
+	"DaemonSet":   "apps/v1",
+	"StatefulSet": "apps/v1",
+}
+
+// K8sEventRecorderHandler creates a Kubernetes Event for each event, so alerts
+// show up in kubectl get events and kubectl describe.
+type K8sEventRecorderHandler struct {
+	broadcaster record.EventBroadcaster
+	// writes recorded Events to the API server
+	sink     watch.Interface
+	recorder record.EventRecorder
+	// pod heartbeats are recorded on, if set
+	pod *api_v1.ObjectReference
+}
+
+// NewK8sEventRecorderHandler returns a handler recording Events through clientset.
+func NewK8sEventRecorderHandler(clientset kubernetes.Interface) *K8sEventRecorderHandler {
+	broadcaster := record.NewBroadcaster()
+	sink := broadcaster.StartRecordingToSink(&typed_core_v1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	return &K8sEventRecorderHandler{
+		broadcaster: broadcaster,
+		sink:        sink,
+		recorder:    broadcaster.NewRecorder(scheme.Scheme, api_v1.EventSource{Component: EventSourceComponent}),
+	}
+}
+
+// SetControllerPod records heartbeats on the controller's own Pod. Without one
+// they aren't recorded, since they aren't about any object.
+func (k *K8sEventRecorderHandler) SetControllerPod(namespace, name string) {
+	k.pod = &api_v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: namespace, Name: name}
+}
+
+// Handle records the event. The broadcaster writes Events asynchronously, so
+// failures to create them are only logged by client-go.
+func (k *K8sEventRecorderHandler) Handle(e Event) error {
+	if e.Kind == heartbeatKind {
+		if k.pod != nil {
+			k.recorder.Event(k.pod, api_v1.EventTypeNormal, e.Reason, fmt.Sprintf("%s: %s", heartbeatKind, e.Reason))
+		}
+		return nil
+	}
+	kind := e.Kind
+	if involved, ok := involvedKinds[kind]; ok {
+		kind = involved
+	}
+	apiVersion, ok := apiVersions[kind]
+	if !ok {
+		apiVersion = "v1"
+	}
+	eventType := api_v1.EventTypeNormal
+	if e.Status == "Warning" || e.Status == "Danger" {
+		eventType = api_v1.EventTypeWarning
+	}
+	ref := &api_v1.ObjectReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  e.Namespace,
+		Name:       e.Name,
+	}
+	// Event reasons are short CamelCase words; longer descriptions go in the message.
+	reason := e.Reason
+	if strings.ContainsAny(reason, " :") {
+		reason = e.Kind
+	}
+	k.recorder.Event(ref, eventType, reason, fmt.Sprintf("%s: %s", e.Status, e.Reason))
+	return nil
+}
+
+// Stop stops recording, dropping Events not written yet.
+func (k *K8sEventRecorderHandler) Stop() {
+	k.sink.Stop()
+	k.broadcaster.Shutdown()
+}
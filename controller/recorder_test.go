@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Returns a recorder on a fake clientset and a function waiting up to a
+// second for it to broadcast n Events.
+func newTestRecorder(t *testing.T) (*K8sEventRecorderHandler, func(n int) []api_v1.Event) {
+	k := NewK8sEventRecorderHandler(fake.NewSimpleClientset())
+	t.Cleanup(k.Stop)
+	var mu sync.Mutex
+	var events []api_v1.Event
+	k.broadcaster.StartEventWatcher(func(e *api_v1.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, *e)
+	})
+	return k, func(n int) []api_v1.Event {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			mu.Lock()
+			got := append([]api_v1.Event(nil), events...)
+			mu.Unlock()
+			if len(got) >= n || time.Now().After(deadline) {
+				return got
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+}
+
+func TestRecorderRecordsOnInvolvedObject(t *testing.T) {
+	k, recorded := newTestRecorder(t)
+
+	k.Handle(Event{Namespace: "default", Kind: "Deployment", Name: "web", Status: "Danger", Reason: "Deleted"})
+
+	events := recorded(1)
+	if len(events) != 1 {
+		t.Fatalf("Got %d Events, want 1", len(events))
+	}
+	e := events[0]
+	if ref := e.InvolvedObject; ref.Kind != "Deployment" || ref.APIVersion != "apps/v1" || ref.Namespace != "default" || ref.Name != "web" {
+		t.Errorf("Got Event on %+v, want Deployment default/web", ref)
+	}
+	if e.Type != api_v1.EventTypeWarning || e.Reason != "Deleted" || e.Source.Component != EventSourceComponent {
+		t.Errorf("Got %s %s from %s, want a Warning Deleted from %s", e.Type, e.Reason, e.Source.Component, EventSourceComponent)
+	}
+}
+
+func TestRecorderHeartbeats(t *testing.T) {
+	k, recorded := newTestRecorder(t)
+	heartbeat := Event{Kind: heartbeatKind, Name: "k8s-controller", Status: "Normal", Reason: "Alive"}
+
+	k.Handle(heartbeat)
+	k.SetControllerPod("monitoring", "k8s-controller-abc")
+	k.Handle(heartbeat)
+
+	events := recorded(2)
+	if len(events) != 1 {
+		t.Fatalf("Got %d Events, want only the heartbeat with a Pod", len(events))
+	}
+	if ref := events[0].InvolvedObject; ref.Kind != "Pod" || ref.Namespace != "monitoring" || ref.Name != "k8s-controller-abc" {
+		t.Errorf("Got heartbeat on %+v, want the controller's Pod", ref)
+	}
+}
@@ -0,0 +1,21 @@
+package controller
+
+// Isolation of panics to the event causing them.
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Processes an event, turning a panic into an error so the event is retried
+// or given up on like any other failure and the worker lives on.
+func (c *Controller) safeProcessItem(newEvent event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicsTotal.Inc()
+			c.logger.WithFields(newEvent.logFields()).WithField("stack", string(debug.Stack())).Errorf("Recovered from panic: %v", r)
+			err = fmt.Errorf("Panic processing event: %v", r)
+		}
+	}()
+	return c.processItem(newEvent)
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Handler which panics on events for one object and passes the rest to inner.
+type panickingHandler struct {
+	name  string
+	inner Handler
+}
+
+func (h *panickingHandler) Handle(e Event) error {
+	if e.Name == h.name {
+		panic("nil map in handler")
+	}
+	return h.inner.Handle(e)
+}
+
+func TestPanicIsolatedToItsKey(t *testing.T) {
+	tc := newTestController(t, Config{})
+	tc.eventHandler = &panickingHandler{name: "web-1", inner: tc.handler}
+	queue := NewFakeRateLimitingQueue()
+	tc.queue = queue
+	before := testutil.ToFloat64(panicsTotal)
+	tc.add("Pod", newPod("web-1"))
+	tc.add("Pod", newPod("web-2"))
+
+	tc.drain()
+
+	if events := tc.handler.Events(); len(events) != 1 || events[0].Name != "web-2" {
+		t.Errorf("Got %+v, want web-2 handled after web-1 panicked", events)
+	}
+	if got := testutil.ToFloat64(panicsTotal) - before; got != 1 {
+		t.Errorf("Counted %v panics, want 1", got)
+	}
+	if len(queue.RateLimited) != 1 || queue.RateLimited[0] != "Pod/default/web-1" {
+		t.Errorf("Got AddRateLimited(%v), want a retry of Pod/default/web-1", queue.RateLimited)
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+// Changing settings of a running controller.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Config fields Reload applies to a running controller. The others only take
+// effect on restart.
+var reloadableFields = map[string]bool{
+	"IgnoreAnnotation":  true,
+	"MinCreateAge":      true,
+	"MaxCreateAge":      true,
+	"MaxItemAge":        true,
+	"UpdateWatchFields": true,
+	"WatchedReasons":    true,
+	"WatchedTypes":      true,
+	"RolloutWindow":     true,
+	"GiveUpPolicy":      true,
+	"MetricsNamespaces": true,
+	"RestartThreshold":  true,
+	"MinObjectAge":      true,
+	"IgnoreManagedBy":   true,
+	"SeverityRules":     true,
+}
+
+// Returns the current config. Reload can change it at any time, so read it once
+// per decision.
+func (c *Controller) settings() Config {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+	return c.config
+}
+
+// Reload applies the reloadable settings of config to the running controller,
+// logging each change. Changes to other settings are logged and ignored. The
+// queue and informers keep running, so no events are lost.
+func (c *Controller) Reload(config Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	current := reflect.ValueOf(&c.config).Elem()
+	next := reflect.ValueOf(config)
+	for i := 0; i < current.NumField(); i++ {
+		name := current.Type().Field(i).Name
+		oldValue, newValue := current.Field(i).Interface(), next.Field(i).Interface()
+		switch oldValue.(type) {
+		case cache.KeyFunc, cache.Indexers:
+			// Code, not settings.
+			continue
+		}
+		// Compare printed values, so nil and empty lists are equal.
+		if fmt.Sprint(oldValue) == fmt.Sprint(newValue) {
+			continue
+		}
+		fields := log.Fields{"setting": name, "old": oldValue, "new": newValue}
+		// Rollout detection can be retuned but not switched on or off.
+		if !reloadableFields[name] || name == "RolloutWindow" && (c.rollouts == nil || config.RolloutWindow == 0) {
+			c.logger.WithFields(fields).Warn("Setting can't be changed while running, ignored")
+			continue
+		}
+		current.Field(i).Set(next.Field(i))
+		c.logger.WithFields(fields).Info("Setting changed")
+	}
+	if c.rollouts != nil {
+		c.rollouts.SetWindow(c.config.RolloutWindow)
+	}
+	return nil
+}
+
+// SetEffectiveConfig sets the settings served at /config, e.g. the merged
+// flag values. Secrets must already be redacted.
+func (c *Controller) SetEffectiveConfig(values map[string]string) {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	c.effective = values
+}
+
+// Serves the effective settings as JSON.
+func (c *Controller) serveConfig(w http.ResponseWriter, r *http.Request) {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.effective)
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+func TestReloadIgnoreAnnotation(t *testing.T) {
+	tc := newTestController(t, Config{})
+	before, after := newPod("web-1"), newPod("web-2")
+	for _, pod := range []*api_v1.Pod{before, after} {
+		pod.Annotations = map[string]string{"alerts/ignore": "true"}
+	}
+	tc.add("Pod", before)
+	tc.drain()
+
+	if err := tc.Reload(Config{IgnoreAnnotation: "alerts/ignore"}); err != nil {
+		t.Fatal(err)
+	}
+	tc.add("Pod", after)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 1 || events[0].Name != "web-1" {
+		t.Fatalf("Got %+v, want only web-1, created before the reload", events)
+	}
+}
+
+func TestReloadKeepsFixedSettings(t *testing.T) {
+	tc := newTestController(t, Config{Workers: 2})
+
+	if err := tc.Reload(Config{Workers: 4, MinCreateAge: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := tc.settings()
+	if settings.Workers != 2 {
+		t.Errorf("Got %d workers, want 2 until restart", settings.Workers)
+	}
+	if settings.MinCreateAge != time.Minute {
+		t.Errorf("Got MinCreateAge %s, want 1m0s", settings.MinCreateAge)
+	}
+}
+
+func TestReloadRejectsInvalidConfig(t *testing.T) {
+	tc := newTestController(t, Config{IgnoreAnnotation: "alerts/ignore"})
+
+	if err := tc.Reload(Config{RetryJitter: -1}); err == nil {
+		t.Fatal("Got no error for a negative retry jitter")
+	}
+	if got := tc.settings().IgnoreAnnotation; got != "alerts/ignore" {
+		t.Errorf("Got IgnoreAnnotation %q after a rejected reload, want alerts/ignore", got)
+	}
+}
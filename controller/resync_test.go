@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestResyncRedeliversWithoutAlerting(t *testing.T) {
+	// Shared informers resync at most once a second.
+	tc := newTestController(t, Config{ResyncPeriod: time.Second}, newDeployment("web"))
+	var resyncs int32
+	tc.informerFor("Deployment").AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			if isResync(old, new) {
+				atomic.AddInt32(&resyncs, 1)
+			}
+		},
+	})
+	tc.start()
+	tc.drain()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for atomic.LoadInt32(&resyncs) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for a resync")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if n := tc.queue.Len(); n != 0 {
+		t.Errorf("Queued %d items on resync, want none", n)
+	}
+	if events := tc.handler.Events(); len(events) != 1 || events[0].Reason != "Created" {
+		t.Errorf("Got %+v, want only the initial Created", events)
+	}
+}
@@ -0,0 +1,142 @@
+package controller
+
+// Collapsing of pod replacements during rollouts into single events.
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A delete held back in case a create for the same owner follows.
+type heldDelete struct {
+	newEvent event
+	kbEvent  Event
+	timer    *time.Timer
+}
+
+// Holds delete events of owned objects for a window. If an object with the
+// same owner is created within the window, both events are replaced by one
+// RolloutProgress event; otherwise the delete is released as is.
+type rolloutDetector struct {
+	// release delivers a held delete once its window passes.
+	release func(newEvent event, kbEvent Event)
+
+	mu      sync.Mutex
+	window  time.Duration
+	pending map[string][]*heldDelete
+	stopped bool
+}
+
+func newRolloutDetector(window time.Duration, release func(event, Event)) *rolloutDetector {
+	return &rolloutDetector{
+		window:  window,
+		release: release,
+		pending: map[string][]*heldDelete{},
+	}
+}
+
+// Changes the window for deletes held from now on.
+func (r *rolloutDetector) SetWindow(window time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.window = window
+}
+
+// Filter returns the event to deliver in place of kbEvent, or false if nothing
+// should be delivered now.
+func (r *rolloutDetector) Filter(newEvent event, kbEvent Event) (Event, bool) {
+	if kbEvent.Owner == "" {
+		return kbEvent, true
+	}
+	owner := kbEvent.Namespace + "/" + kbEvent.Owner
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return kbEvent, true
+	}
+	switch newEvent.eventType {
+	case "delete":
+		held := &heldDelete{newEvent: newEvent, kbEvent: kbEvent}
+		held.timer = time.AfterFunc(r.window, func() {
+			if r.remove(owner, held) {
+				r.release(held.newEvent, held.kbEvent)
+			}
+		})
+		r.pending[owner] = append(r.pending[owner], held)
+		return Event{}, false
+	case "create":
+		held := r.pending[owner]
+		if len(held) == 0 {
+			return kbEvent, true
+		}
+		deleted := held[0]
+		deleted.timer.Stop()
+		r.pending[owner] = held[1:]
+		if len(r.pending[owner]) == 0 {
+			delete(r.pending, owner)
+		}
+		return Event{
+			Name:      kbEvent.Owner,
+			Namespace: kbEvent.Namespace,
+			Kind:      "RolloutProgress",
+			Status:    "Normal",
+			Reason:    fmt.Sprintf("%s %s replaced by %s", kbEvent.Kind, deleted.kbEvent.Name, kbEvent.Name),
+			Owner:     kbEvent.Owner,
+			Labels:    kbEvent.Labels,
+			Timestamp: kbEvent.Timestamp,
+		}, true
+	}
+	return kbEvent, true
+}
+
+// Stop releases every held delete at once and stops holding new ones, so they
+// are delivered before shutdown.
+func (r *rolloutDetector) Stop() {
+	r.mu.Lock()
+	r.stopped = true
+	var released []*heldDelete
+	for owner, held := range r.pending {
+		for _, h := range held {
+			if h.timer.Stop() {
+				released = append(released, h)
+			}
+		}
+		delete(r.pending, owner)
+	}
+	r.mu.Unlock()
+	for _, h := range released {
+		r.release(h.newEvent, h.kbEvent)
+	}
+}
+
+// Removes held from the pending deletes of owner, reporting whether it was there.
+func (r *rolloutDetector) remove(owner string, held *heldDelete) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, h := range r.pending[owner] {
+		if h == held {
+			r.pending[owner] = append(r.pending[owner][:i], r.pending[owner][i+1:]...)
+			if len(r.pending[owner]) == 0 {
+				delete(r.pending, owner)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Queues a delete whose rollout window passed, so it is delivered, retried and
+// drained like any other event.
+func (c *Controller) releaseDelete(newEvent event, kbEvent Event) {
+	// processItem cut the key down to the name
+	if newEvent.namespace != "" {
+		newEvent.key = newEvent.namespace + "/" + newEvent.key
+	}
+	newEvent.eventType = "release"
+	newEvent.delivery = &kbEvent
+	key := c.pending.Set(newEvent)
+	c.firstSeen.Add(key, time.Now())
+	c.queue.Add(key)
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRolloutReplacesDeleteAndCreate(t *testing.T) {
+	rs, old := newOwnedPod()
+	tc := newTestController(t, Config{RolloutWindow: time.Minute}, rs)
+	replacement := newPod("web-7d8f-abc")
+	replacement.OwnerReferences = old.OwnerReferences
+	tc.add("Pod", old)
+	tc.drain()
+	tc.remove("Pod", old)
+	tc.drain()
+	tc.add("Pod", replacement)
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 2 || events[0].Reason != "Created" {
+		t.Fatalf("Got %+v, want Created then RolloutProgress", events)
+	}
+	if e := events[1]; e.Kind != "RolloutProgress" || e.Name != "web" {
+		t.Errorf("Got %+v, want RolloutProgress for web", e)
+	}
+}
+
+func TestRolloutReleasesDeleteThroughQueue(t *testing.T) {
+	rs, pod := newOwnedPod()
+	tc := newTestController(t, Config{RolloutWindow: 10 * time.Millisecond}, rs)
+	tc.add("Pod", pod)
+	tc.drain()
+	tc.remove("Pod", pod)
+	tc.drain()
+	tc.handler.SetErr(errors.New("sink down"))
+
+	tc.waitFor("the delete to be released", func() bool { return tc.queue.Len() > 0 })
+	queue := NewFakeRateLimitingQueue()
+	key, _ := tc.queue.Get()
+	tc.queue = queue
+	queue.Add(key)
+	tc.processNextItem()
+
+	if len(queue.RateLimited) != 1 {
+		t.Fatalf("Got AddRateLimited(%v), want the released delete retried", queue.RateLimited)
+	}
+	tc.handler.SetErr(nil)
+	queue.Add(key)
+	tc.processNextItem()
+
+	events := tc.handler.Events()
+	if len(events) != 2 || events[1].Reason != "Deleted" || events[1].Name != pod.Name {
+		t.Fatalf("Got %+v, want Created then Deleted for %s", events, pod.Name)
+	}
+}
+
+func TestRolloutStopReleasesHeldDeletes(t *testing.T) {
+	rs, pod := newOwnedPod()
+	tc := newTestController(t, Config{RolloutWindow: time.Hour}, rs)
+	tc.add("Pod", pod)
+	tc.drain()
+	tc.remove("Pod", pod)
+	tc.drain()
+
+	tc.rollouts.Stop()
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 2 || events[1].Reason != "Deleted" {
+		t.Fatalf("Got %+v, want Created then Deleted", events)
+	}
+}
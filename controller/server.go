@@ -0,0 +1,52 @@
+package controller
+
+// HTTP server exposing metrics and controller state.
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"ohthehugemanatee/k8s-controller-demo/version"
+)
+
+// Builds the mux served on the metrics address.
+func (c *Controller) newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/events", c.history)
+	mux.HandleFunc("/status", c.serveStatus)
+	mux.HandleFunc("/readyz", c.serveReady)
+	mux.HandleFunc("/config", c.serveConfig)
+	mux.HandleFunc("/version", version.ServeHTTP)
+	return mux
+}
+
+// Serves metrics and controller state until the listener fails.
+func (c *Controller) serveHTTP(addr string) {
+	c.logger.Infof("Serving metrics on %s", addr)
+	if err := http.ListenAndServe(addr, c.newServeMux()); err != nil {
+		c.logger.WithError(err).Error("Metrics server stopped")
+	}
+}
+
+// Builds the mux for the pprof handlers. They get their own mux and address
+// rather than http.DefaultServeMux, so they are only served when asked for.
+func newPprofMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// Serves the pprof handlers until the listener fails.
+func (c *Controller) servePprof(addr string) {
+	c.logger.Infof("Serving pprof on %s", addr)
+	if err := http.ListenAndServe(addr, newPprofMux()); err != nil {
+		c.logger.WithError(err).Error("pprof server stopped")
+	}
+}
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPprofServedOnItsOwnMux(t *testing.T) {
+	c := NewController(nil, nil, Config{})
+	for _, test := range []struct {
+		name string
+		mux  *http.ServeMux
+		code int
+	}{
+		{"pprof", newPprofMux(), http.StatusOK},
+		{"metrics", c.newServeMux(), http.StatusNotFound},
+	} {
+		recorder := httptest.NewRecorder()
+		test.mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/pprof/", nil))
+		if recorder.Code != test.code {
+			t.Errorf("/debug/pprof/ on the %s mux returned %d, want %d", test.name, recorder.Code, test.code)
+		}
+	}
+}
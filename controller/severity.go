@@ -0,0 +1,141 @@
+package controller
+
+// Filtering of events by severity.
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Event statuses from least to most severe.
+var severityRank = map[string]int{
+	"Normal":  0,
+	"Warning": 1,
+	"Danger":  2,
+}
+
+// SeverityFilterHandler drops events less severe than min before passing them to inner.
+type SeverityFilterHandler struct {
+	mu    sync.RWMutex
+	min   string
+	inner Handler
+}
+
+// NewSeverityFilterHandler returns a filter passing events of severity min
+// (Normal, Warning or Danger) or above to inner.
+func NewSeverityFilterHandler(min string, inner Handler) (*SeverityFilterHandler, error) {
+	if err := validSeverity(min); err != nil {
+		return nil, err
+	}
+	return &SeverityFilterHandler{min: min, inner: inner}, nil
+}
+
+func validSeverity(severity string) error {
+	if _, ok := severityRank[severity]; !ok {
+		return fmt.Errorf("Unknown severity %q, expected Normal, Warning or Danger", severity)
+	}
+	return nil
+}
+
+// Min returns the minimum severity passed on.
+func (s *SeverityFilterHandler) Min() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.min
+}
+
+// SetMin changes the minimum severity of a running filter.
+func (s *SeverityFilterHandler) SetMin(min string) error {
+	if err := validSeverity(min); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.min = min
+	return nil
+}
+
+// Reports whether the event is severe enough. Unknown statuses, heartbeats
+// and resolutions always pass. A resolution is Normal, but has to reach the
+// sinks which got the alert it resolves.
+func (s *SeverityFilterHandler) passes(e Event) bool {
+	if _, resolves := resolvedCondition(e); resolves {
+		return true
+	}
+	rank, ok := severityRank[e.Status]
+	return !ok || rank >= severityRank[s.Min()] || e.Kind == heartbeatKind
+}
+
+// Handle passes the event on if it is severe enough.
+func (s *SeverityFilterHandler) Handle(e Event) error {
+	if !s.passes(e) {
+		return nil
+	}
+	return s.inner.Handle(e)
+}
+
+// HandleBatch passes the events which are severe enough on as one batch and
+// returns the result of each event. Dropped events succeed.
+func (s *SeverityFilterHandler) HandleBatch(events []Event) []error {
+	errs := make([]error, len(events))
+	var passed []Event
+	var indexes []int
+	for i, e := range events {
+		if s.passes(e) {
+			passed = append(passed, e)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(passed) == 0 {
+		return errs
+	}
+	for j, err := range handleBatch(s.inner, passed) {
+		errs[indexes[j]] = err
+	}
+	return errs
+}
+
+// Stop stops the inner handler.
+func (s *SeverityFilterHandler) Stop() {
+	stopHandler(s.inner)
+}
+
+// Handler which drops some events, so they needn't be fully prepared.
+type eventFilter interface {
+	wants(e Event) bool
+}
+
+// Reports whether h does anything with e. Handlers which aren't eventFilters
+// want everything.
+func handlerWants(h Handler, e Event) bool {
+	if f, ok := h.(eventFilter); ok {
+		return f.wants(e)
+	}
+	return true
+}
+
+func (s *SeverityFilterHandler) wants(e Event) bool {
+	return s.passes(e) && handlerWants(s.inner, e)
+}
+
+// Any of the handlers may want the event.
+func (m *MultiHandler) wants(e Event) bool {
+	for _, h := range m.handlers {
+		if handlerWants(h, e) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *DryRunHandler) wants(e Event) bool {
+	return handlerWants(d.inner, e)
+}
+
+func (b *BatchHandler) wants(e Event) bool {
+	return handlerWants(b.inner, e)
+}
+
+func (b *CircuitBreakerHandler) wants(e Event) bool {
+	return handlerWants(b.inner, e)
+}
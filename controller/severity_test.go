@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"testing"
+)
+
+func TestSeverityFilter(t *testing.T) {
+	tests := []struct {
+		min, status string
+		passes      bool
+	}{
+		{"Normal", "Normal", true},
+		{"Normal", "Warning", true},
+		{"Normal", "Danger", true},
+		{"Warning", "Normal", false},
+		{"Warning", "Warning", true},
+		{"Warning", "Danger", true},
+		{"Danger", "Normal", false},
+		{"Danger", "Warning", false},
+		{"Danger", "Danger", true},
+		{"Danger", "Unknown", true},
+	}
+	for _, test := range tests {
+		inner := &captureHandler{}
+		filter, err := NewSeverityFilterHandler(test.min, inner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filter.Handle(Event{Status: test.status})
+		if passed := len(inner.Events()) == 1; passed != test.passes {
+			t.Errorf("Min %s passed %s %t, want %t", test.min, test.status, passed, test.passes)
+		}
+	}
+}
+
+func TestSeverityFilterPassesHeartbeats(t *testing.T) {
+	inner := &captureHandler{}
+	filter, err := NewSeverityFilterHandler("Danger", inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter.Handle(Event{Kind: heartbeatKind, Status: "Normal"})
+	if len(inner.Events()) != 1 {
+		t.Error("Heartbeat filtered out")
+	}
+}
+
+func TestSeverityFilterSetMin(t *testing.T) {
+	inner := &captureHandler{}
+	filter, err := NewSeverityFilterHandler("Normal", inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := filter.SetMin("Danger"); err != nil {
+		t.Fatal(err)
+	}
+	filter.Handle(Event{Status: "Warning"})
+	if len(inner.Events()) != 0 {
+		t.Error("Warning passed after raising the minimum to Danger")
+	}
+	if err := filter.SetMin("Critical"); err == nil || filter.Min() != "Danger" {
+		t.Errorf("SetMin(Critical) returned %v and left min %s, want an error and Danger", err, filter.Min())
+	}
+	if _, err := NewSeverityFilterHandler("Critical", inner); err == nil {
+		t.Error("Got no error for minimum severity Critical")
+	}
+}
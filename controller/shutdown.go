@@ -0,0 +1,59 @@
+package controller
+
+// Draining the queue on shutdown.
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Stops accepting new items, waits for the workers to process what is queued
+// and stops the handler, so it flushes anything it still holds. After timeout,
+// if set, it stops waiting, so a hanging handler can't block the exit forever.
+// Events left over are dead-lettered.
+func (c *Controller) drain(workers *sync.WaitGroup, timeout time.Duration) {
+	// deletes held for rollout detection join the queue while it takes items
+	if c.rollouts != nil {
+		c.rollouts.Stop()
+	}
+	c.queue.ShutDown()
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	if !runUntil(expired, workers.Wait) {
+		c.logger.Warn("Shutdown timeout reached before the queue drained")
+	} else if !runUntil(expired, func() { stopHandler(c.eventHandler) }) {
+		c.logger.Warn("Shutdown timeout reached before the handler stopped")
+	}
+	// Left over are events still queued at the timeout, or failed events whose
+	// retry the shut down queue refused, including those the handler failed
+	// to flush.
+	undrained := c.pending.TakeAll()
+	if len(undrained) == 0 {
+		c.logger.Info("Queue drained")
+		return
+	}
+	c.logger.WithField("undrained", len(undrained)).Warn("Events not processed before shutdown")
+	for _, e := range undrained {
+		c.deadLetterEvent(e, fmt.Errorf("Not processed before shutdown"))
+	}
+}
+
+// Runs f, reporting whether it returned before expired fired.
+func runUntil(expired <-chan time.Time, f func()) bool {
+	done := make(chan struct{})
+	go func() {
+		f()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-expired:
+		return false
+	}
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// Handler which never returns, like a webhook which doesn't respond.
+type hangingHandler struct {
+	called chan struct{}
+}
+
+func (h hangingHandler) Handle(e Event) error {
+	select {
+	case h.called <- struct{}{}:
+	default:
+	}
+	select {}
+}
+
+func TestRunReturnsAfterShutdownTimeout(t *testing.T) {
+	logs := test.NewGlobal()
+	tc := newTestController(t, Config{ShutdownTimeout: 100 * time.Millisecond, Backfill: true}, newPod("web-0"), newPod("web-1"))
+	hanging := hangingHandler{called: make(chan struct{}, 1)}
+	tc.eventHandler = hanging
+
+	stopCh := make(chan struct{})
+	returned := make(chan error)
+	go func() { returned <- tc.Run(stopCh) }()
+	select {
+	case <-hanging.called:
+	case <-time.After(time.Second):
+		t.Fatal("Handler never called")
+	}
+	close(stopCh)
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the shutdown timeout")
+	}
+	var undrained interface{}
+	for _, entry := range logs.AllEntries() {
+		if n, ok := entry.Data["undrained"]; ok {
+			undrained = n
+		}
+	}
+	if undrained != 1 {
+		t.Errorf("Logged %v undrained events, want 1", undrained)
+	}
+}
+
+// Handler which takes a while for each event.
+type slowHandler struct {
+	captureHandler
+}
+
+func (h *slowHandler) Handle(e Event) error {
+	time.Sleep(20 * time.Millisecond)
+	return h.captureHandler.Handle(e)
+}
+
+func TestRunDrainsQueueOnStop(t *testing.T) {
+	tc := newTestController(t, Config{Backfill: true}, newPod("web-0"), newPod("web-1"), newPod("web-2"))
+	slow := &slowHandler{}
+	tc.eventHandler = slow
+
+	stopCh := make(chan struct{})
+	returned := make(chan error)
+	go func() { returned <- tc.Run(stopCh) }()
+	tc.waitFor("the first event", func() bool { return slow.Calls() > 0 })
+	close(stopCh)
+
+	if err := <-returned; err != nil {
+		t.Fatal(err)
+	}
+	if events := slow.Events(); len(events) != 3 {
+		t.Errorf("Got %d events, want all 3 queued events handled before Run returned", len(events))
+	}
+}
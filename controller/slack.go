@@ -0,0 +1,80 @@
+package controller
+
+// Handler which posts events to a Slack incoming webhook.
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SlackHandler sends events to Slack as message attachments.
+type SlackHandler struct {
+	WebhookURL string
+	// DashboardBaseURL, if set, links each message to {base}/{namespace}/{kind}/{name}.
+	DashboardBaseURL string
+	Client           *http.Client
+}
+
+type slackMessage struct {
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Fallback  string       `json:"fallback"`
+	Color     string       `json:"color"`
+	Title     string       `json:"title"`
+	TitleLink string       `json:"title_link,omitempty"`
+	Fields    []slackField `json:"fields"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// Handle posts the event.
+func (s *SlackHandler) Handle(e Event) error {
+	return postJSON(s.Client, s.WebhookURL, s.message(e))
+}
+
+func (s *SlackHandler) message(e Event) slackMessage {
+	title := fmt.Sprintf("[%s] %s %s %s", e.Status, e.Kind, e.Name, e.Reason)
+	return slackMessage{
+		Attachments: []slackAttachment{{
+			Fallback:  title,
+			Color:     "#" + statusColor(e.Status),
+			Title:     title,
+			TitleLink: dashboardURL(s.DashboardBaseURL, e),
+			Fields: []slackField{
+				{Title: "Namespace", Value: e.Namespace, Short: true},
+				{Title: "Kind", Value: e.Kind, Short: true},
+				{Title: "Reason", Value: e.Reason, Short: true},
+				{Title: "Timestamp", Value: e.Timestamp.Format(time.RFC3339), Short: true},
+			},
+		}},
+	}
+}
+
+// Builds {base}/{namespace}/{kind}/{name}, or "" without a base.
+func dashboardURL(base string, e Event) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimSuffix(base, "/") + "/" + url.PathEscape(e.Namespace) + "/" + url.PathEscape(e.Kind) + "/" + url.PathEscape(e.Name)
+}
+
+// Maps an event status to a red, amber or green hex color.
+func statusColor(status string) string {
+	switch status {
+	case "Danger":
+		return "D7000C"
+	case "Warning":
+		return "FFBF00"
+	default:
+		return "2EB886"
+	}
+}
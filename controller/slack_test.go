@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSlackAttachmentColor(t *testing.T) {
+	tests := []struct {
+		status, color string
+	}{
+		{"Danger", "#D7000C"},
+		{"Warning", "#FFBF00"},
+		{"Normal", "#2EB886"},
+	}
+	for _, test := range tests {
+		var message slackMessage
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+				t.Error(err)
+			}
+		}))
+		h := &SlackHandler{WebhookURL: server.URL}
+		err := h.Handle(Event{Namespace: "default", Kind: "Pod", Name: "web-1", Status: test.status, Reason: "Deleted", Timestamp: time.Now()})
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(message.Attachments) != 1 {
+			t.Fatalf("Got %d attachments, want 1", len(message.Attachments))
+		}
+		attachment := message.Attachments[0]
+		if attachment.Color != test.color {
+			t.Errorf("Status %s got color %s, want %s", test.status, attachment.Color, test.color)
+		}
+		if attachment.TitleLink != "" {
+			t.Errorf("Got title link %q without a dashboard, want none", attachment.TitleLink)
+		}
+	}
+}
+
+func TestSlackDashboardLink(t *testing.T) {
+	h := &SlackHandler{DashboardBaseURL: "https://dash.example.com/k8s/"}
+	message := h.message(Event{Namespace: "default", Kind: "Pod", Name: "web 1"})
+
+	if got, want := message.Attachments[0].TitleLink, "https://dash.example.com/k8s/default/Pod/web%201"; got != want {
+		t.Errorf("Got title link %q, want %q", got, want)
+	}
+}
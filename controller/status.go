@@ -0,0 +1,58 @@
+package controller
+
+// Controller state served at /status.
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Highest resourceVersion processed per resource kind.
+type versionTracker struct {
+	mu       sync.Mutex
+	versions map[string]uint64
+}
+
+func newVersionTracker() *versionTracker {
+	return &versionTracker{versions: map[string]uint64{}}
+}
+
+// Observe records resourceVersion for kind if it is higher than any seen so far.
+// resourceVersions are opaque to clients, but the apiserver's etcd backed ones
+// are increasing integers; anything else is ignored.
+func (t *versionTracker) Observe(kind, resourceVersion string) {
+	version, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if version > t.versions[kind] {
+		t.versions[kind] = version
+	}
+}
+
+// Versions returns a copy of the tracked versions.
+func (t *versionTracker) Versions() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	versions := make(map[string]string, len(t.versions))
+	for kind, version := range t.versions {
+		versions[kind] = strconv.FormatUint(version, 10)
+	}
+	return versions
+}
+
+type statusResponse struct {
+	ResourceVersions map[string]string `json:"resourceVersions"`
+}
+
+// Serves the controller's processing state as JSON.
+func (c *Controller) serveStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statusResponse{
+		ResourceVersions: c.versions.Versions(),
+	})
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVersionTrackerNeverGoesBackward(t *testing.T) {
+	tracker := newVersionTracker()
+	for _, observed := range []struct{ version, want string }{
+		{"5", "5"},
+		{"12", "12"},
+		{"9", "12"},
+		{"not a number", "12"},
+		{"100", "100"},
+	} {
+		tracker.Observe("Pod", observed.version)
+		if got := tracker.Versions()["Pod"]; got != observed.want {
+			t.Errorf("After %s got %s, want %s", observed.version, got, observed.want)
+		}
+	}
+	if _, ok := tracker.Versions()["Deployment"]; ok {
+		t.Error("Tracked a version for Deployment, want kinds tracked separately")
+	}
+}
+
+func TestStatusServesProcessedVersions(t *testing.T) {
+	tc := newTestController(t, Config{})
+	for _, version := range []string{"7", "3"} {
+		pod := newPod("web-" + version)
+		pod.ResourceVersion = version
+		tc.add("Pod", pod)
+	}
+	tc.drain()
+
+	recorder := httptest.NewRecorder()
+	tc.serveStatus(recorder, httptest.NewRequest("GET", "/status", nil))
+	var status statusResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if got := status.ResourceVersions["Pod"]; got != "7" {
+		t.Errorf("Got Pod version %q, want 7", got)
+	}
+}
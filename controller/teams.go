@@ -0,0 +1,58 @@
+package controller
+
+// Handler which posts MessageCards to a Microsoft Teams incoming webhook.
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TeamsHandler sends events to a Teams connector.
+type TeamsHandler struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+type teamsMessageCard struct {
+	Type       string         `json:"@type"`
+	Context    string         `json:"@context"`
+	ThemeColor string         `json:"themeColor"`
+	Summary    string         `json:"summary"`
+	Title      string         `json:"title"`
+	Sections   []teamsSection `json:"sections"`
+}
+
+type teamsSection struct {
+	Facts []teamsFact `json:"facts"`
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Handle posts the event as a MessageCard.
+func (t *TeamsHandler) Handle(e Event) error {
+	return postJSON(t.Client, t.WebhookURL, newTeamsMessageCard(e))
+}
+
+func newTeamsMessageCard(e Event) teamsMessageCard {
+	title := fmt.Sprintf("[%s] %s %s %s", e.Status, e.Kind, e.Name, e.Reason)
+	return teamsMessageCard{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		ThemeColor: statusColor(e.Status),
+		Summary:    title,
+		Title:      title,
+		Sections: []teamsSection{{
+			Facts: []teamsFact{
+				{Name: "Namespace", Value: e.Namespace},
+				{Name: "Kind", Value: e.Kind},
+				{Name: "Name", Value: e.Name},
+				{Name: "Reason", Value: e.Reason},
+				{Name: "Timestamp", Value: e.Timestamp.Format(time.RFC3339)},
+			},
+		}},
+	}
+}
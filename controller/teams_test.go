@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTeamsMessageCard(t *testing.T) {
+	tests := []struct {
+		status, color string
+	}{
+		{"Danger", "D7000C"},
+		{"Warning", "FFBF00"},
+		{"Normal", "2EB886"},
+	}
+	for _, test := range tests {
+		var card map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Got Content-Type %q, want application/json", ct)
+			}
+			if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
+				t.Error(err)
+			}
+		}))
+		h := &TeamsHandler{WebhookURL: server.URL}
+		err := h.Handle(Event{Namespace: "default", Kind: "Pod", Name: "web-1", Status: test.status, Reason: "Deleted", Timestamp: time.Now()})
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if card["@type"] != "MessageCard" || card["@context"] != "https://schema.org/extensions" {
+			t.Errorf("Got card type %v and context %v, want a MessageCard", card["@type"], card["@context"])
+		}
+		if card["themeColor"] != test.color {
+			t.Errorf("Status %s got color %v, want %s", test.status, card["themeColor"], test.color)
+		}
+		if want := "[" + test.status + "] Pod web-1 Deleted"; card["title"] != want {
+			t.Errorf("Got title %v, want %s", card["title"], want)
+		}
+		sections, _ := card["sections"].([]interface{})
+		if len(sections) != 1 {
+			t.Fatalf("Got sections %v, want one", card["sections"])
+		}
+		facts := map[string]interface{}{}
+		for _, fact := range sections[0].(map[string]interface{})["facts"].([]interface{}) {
+			fact := fact.(map[string]interface{})
+			facts[fact["name"].(string)] = fact["value"]
+		}
+		if facts["Namespace"] != "default" || facts["Kind"] != "Pod" || facts["Name"] != "web-1" || facts["Reason"] != "Deleted" {
+			t.Errorf("Got facts %v", facts)
+		}
+	}
+}
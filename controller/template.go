@@ -0,0 +1,79 @@
+package controller
+
+// Handler which renders events through a user supplied text/template.
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// TemplateHandler renders each event with tmpl and passes the result to inner.
+// The template sees every Event field, e.g. {{.Namespace}}/{{.Name}}.
+type TemplateHandler struct {
+	tmpl  *template.Template
+	inner func(string) error
+}
+
+// NewTemplateHandler parses text up front, so a broken template fails at startup
+// rather than on every event.
+func NewTemplateHandler(text string, output func(string) error) (*TemplateHandler, error) {
+	tmpl, err := template.New("event").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing event template: %v", err)
+	}
+	return &TemplateHandler{tmpl: tmpl, inner: output}, nil
+}
+
+// Handle renders the event and outputs it.
+func (t *TemplateHandler) Handle(e Event) error {
+	var b bytes.Buffer
+	if err := t.tmpl.Execute(&b, e); err != nil {
+		return err
+	}
+	return t.inner(b.String())
+}
+
+// WriterOutput writes each rendered event to w on its own line.
+func WriterOutput(w io.Writer) func(string) error {
+	var mu sync.Mutex
+	return func(s string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := fmt.Fprintln(w, s)
+		return err
+	}
+}
+
+// FileOutput appends each rendered event to the file at path.
+func FileOutput(path string) (func(string) error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return WriterOutput(f), nil
+}
+
+// WebhookOutput POSTs each rendered event as a text/plain body to url.
+// Without a client, requests time out after DefaultHTTPTimeout.
+func WebhookOutput(client *http.Client, url string) func(string) error {
+	if client == nil {
+		client = defaultClient
+	}
+	return func(s string) error {
+		resp, err := client.Post(url, "text/plain; charset=utf-8", strings.NewReader(s))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("POST %s returned %s", url, resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTemplateRendersFields(t *testing.T) {
+	var out bytes.Buffer
+	h, err := NewTemplateHandler("[{{.Status}}] {{.Kind}} {{.Namespace}}/{{.Name}}: {{.Reason}}", WriterOutput(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Handle(Event{Kind: "Pod", Namespace: "default", Name: "web-1", Reason: "Created", Status: "Normal"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[Normal] Pod default/web-1: Created\n"; out.String() != want {
+		t.Errorf("Rendered %q, want %q", out.String(), want)
+	}
+}
+
+func TestTemplateParseError(t *testing.T) {
+	if _, err := NewTemplateHandler("{{.Name", WriterOutput(&bytes.Buffer{})); err == nil {
+		t.Error("Got no error for an unclosed action")
+	}
+}
+
+func TestTemplateUnknownField(t *testing.T) {
+	h, err := NewTemplateHandler("{{.Cluster}}", WriterOutput(&bytes.Buffer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Handle(Event{Name: "web-1"}); err == nil {
+		t.Error("Got no error rendering a field Event lacks")
+	}
+}
@@ -0,0 +1,230 @@
+package controller
+
+// Test harness running a Controller against a fake clientset.
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Handler which records every event it is passed. While err is set it fails
+// instead.
+type captureHandler struct {
+	mu     sync.Mutex
+	events []Event
+	calls  int
+	err    error
+}
+
+func (h *captureHandler) Handle(e Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	if h.err != nil {
+		return h.err
+	}
+	h.events = append(h.events, e)
+	return nil
+}
+
+// Events returns the events handled so far.
+func (h *captureHandler) Events() []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Event(nil), h.events...)
+}
+
+// Calls returns how often Handle was called, including failed calls.
+func (h *captureHandler) Calls() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls
+}
+
+// Fails every following call with err, or succeeds again if err is nil.
+func (h *captureHandler) SetErr(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.err = err
+}
+
+// Controller backed by a fake clientset, with informers for Deployments, Pods,
+// core Events, Secrets and ConfigMaps. Informers only run after start; until
+// then the add, update and remove helpers stand in for them.
+type testController struct {
+	*Controller
+	t       *testing.T
+	client  *fake.Clientset
+	handler *captureHandler
+	stopCh  chan struct{}
+}
+
+// Returns a harness whose fake clientset holds objects. It is stopped when the
+// test ends.
+func newTestController(t *testing.T, config Config, objects ...runtime.Object) *testController {
+	client := fake.NewSimpleClientset(objects...)
+	handler := &captureHandler{}
+	tc := &testController{
+		Controller: NewController(client, handler, config),
+		t:          t,
+		client:     client,
+		handler:    handler,
+		stopCh:     make(chan struct{}),
+	}
+	for resourceType, informer := range map[string]cache.SharedIndexInformer{
+		"Deployment": NewInformer(&cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return client.AppsV1().Deployments("").List(context.TODO(), options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return client.AppsV1().Deployments("").Watch(context.TODO(), options)
+			},
+		}, &apps_v1.Deployment{}, config),
+		"Pod": NewInformer(&cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().Pods("").List(context.TODO(), options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().Pods("").Watch(context.TODO(), options)
+			},
+		}, &api_v1.Pod{}, config),
+		"Event": NewInformer(&cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().Events("").List(context.TODO(), options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().Events("").Watch(context.TODO(), options)
+			},
+		}, &api_v1.Event{}, config),
+		"Secret": NewInformer(&cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().Secrets("").List(context.TODO(), options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().Secrets("").Watch(context.TODO(), options)
+			},
+		}, &api_v1.Secret{}, config),
+		"ConfigMap": NewInformer(&cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().ConfigMaps("").List(context.TODO(), options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().ConfigMaps("").Watch(context.TODO(), options)
+			},
+		}, &api_v1.ConfigMap{}, config),
+	} {
+		tc.AddInformer(resourceType, informer)
+	}
+	t.Cleanup(func() {
+		close(tc.stopCh)
+		tc.queue.ShutDown()
+	})
+	return tc
+}
+
+// Runs the informers and waits for their caches to sync. Objects written
+// through client from now on are queued by the informers.
+func (tc *testController) start() {
+	tc.t.Helper()
+	for _, ri := range tc.informers {
+		go ri.informer.Run(tc.stopCh)
+	}
+	if !cache.WaitForCacheSync(tc.stopCh, tc.HasSynced) {
+		tc.t.Fatal("Caches did not sync")
+	}
+}
+
+// Stores obj in the informer cache of resourceType and queues a create event,
+// as the informer would.
+func (tc *testController) add(resourceType string, obj interface{}) {
+	tc.t.Helper()
+	if err := tc.informerFor(resourceType).GetIndexer().Add(obj); err != nil {
+		tc.t.Fatal(err)
+	}
+	tc.informerHandler(resourceType).OnAdd(obj)
+}
+
+// Stores new in the informer cache of resourceType and queues an update event.
+// Like the apiserver, it gives new the next resource version if it has the
+// same one as old.
+func (tc *testController) update(resourceType string, old, new interface{}) {
+	tc.t.Helper()
+	oldMeta, err := meta.Accessor(old)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	newMeta, err := meta.Accessor(new)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	if newMeta.GetResourceVersion() == oldMeta.GetResourceVersion() {
+		version, _ := strconv.Atoi(oldMeta.GetResourceVersion())
+		newMeta.SetResourceVersion(strconv.Itoa(version + 1))
+	}
+	if err := tc.informerFor(resourceType).GetIndexer().Update(new); err != nil {
+		tc.t.Fatal(err)
+	}
+	tc.informerHandler(resourceType).OnUpdate(old, new)
+}
+
+// Removes obj from the informer cache of resourceType and queues a delete event.
+func (tc *testController) remove(resourceType string, obj interface{}) {
+	tc.t.Helper()
+	if err := tc.informerFor(resourceType).GetIndexer().Delete(obj); err != nil {
+		tc.t.Fatal(err)
+	}
+	tc.informerHandler(resourceType).OnDelete(obj)
+}
+
+// Processes queued items until none is ready. Items requeued with a delay,
+// e.g. retries, are left for a later drain.
+func (tc *testController) drain() {
+	for tc.queue.Len() > 0 {
+		tc.processNextItem()
+	}
+}
+
+// Waits up to a second for cond to hold.
+func (tc *testController) waitFor(what string, cond func() bool) {
+	tc.t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			tc.t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Returns a Deployment in namespace default created now.
+func newDeployment(name string) *apps_v1.Deployment {
+	return &apps_v1.Deployment{ObjectMeta: newObjectMeta(name)}
+}
+
+// Returns a Pod in namespace default created now.
+func newPod(name string) *api_v1.Pod {
+	return &api_v1.Pod{ObjectMeta: newObjectMeta(name)}
+}
+
+func newObjectMeta(name string) meta_v1.ObjectMeta {
+	return meta_v1.ObjectMeta{
+		Name:              name,
+		Namespace:         "default",
+		UID:               types.UID("uid-" + name),
+		ResourceVersion:   "1",
+		CreationTimestamp: meta_v1.Now(),
+	}
+}
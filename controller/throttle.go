@@ -0,0 +1,71 @@
+package controller
+
+// Per-object rate limiting of outbound alerts.
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Token bucket per namespace/kind/name key. A bucket left alone for a minute
+// has refilled completely, no different from a new one, so idle buckets are
+// dropped and the map only holds objects alerting within the last minute.
+type alertThrottle struct {
+	perMinute int
+
+	mu        sync.Mutex
+	limiters  map[string]*throttleEntry
+	lastSweep time.Time
+}
+
+type throttleEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// How long a bucket takes to refill, after which it can be dropped.
+const throttleIdle = time.Minute
+
+func newAlertThrottle(perMinute int) *alertThrottle {
+	return &alertThrottle{
+		perMinute: perMinute,
+		limiters:  map[string]*throttleEntry{},
+	}
+}
+
+// Allow reports whether another alert may be sent for e. A throttle with no
+// limit configured allows everything.
+func (t *alertThrottle) Allow(e Event) bool {
+	return t.allow(e, time.Now())
+}
+
+func (t *alertThrottle) allow(e Event, now time.Time) bool {
+	if t.perMinute <= 0 {
+		return true
+	}
+	key := e.Namespace + "/" + e.Kind + "/" + e.Name
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.lastSweep) >= throttleIdle {
+		t.sweep(now)
+	}
+	entry, ok := t.limiters[key]
+	if !ok {
+		entry = &throttleEntry{limiter: rate.NewLimiter(rate.Limit(float64(t.perMinute)/60), t.perMinute)}
+		t.limiters[key] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter.AllowN(now, 1)
+}
+
+// Drops the buckets idle for long enough to have refilled. The caller holds mu.
+func (t *alertThrottle) sweep(now time.Time) {
+	for key, entry := range t.limiters {
+		if now.Sub(entry.lastSeen) >= throttleIdle {
+			delete(t.limiters, key)
+		}
+	}
+	t.lastSweep = now
+}
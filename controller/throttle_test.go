@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestThrottleLimitsBurstPerObject(t *testing.T) {
+	throttle := newAlertThrottle(3)
+	now := time.Now()
+	web, db := Event{Namespace: "default", Kind: "Pod", Name: "web"}, Event{Namespace: "default", Kind: "Pod", Name: "db"}
+
+	var allowed int
+	for i := 0; i < 20; i++ {
+		if throttle.allow(web, now) {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("Allowed %d of 20 updates, want 3", allowed)
+	}
+	if !throttle.allow(db, now) {
+		t.Error("Throttled another object")
+	}
+	if !throttle.allow(web, now.Add(20*time.Second)) {
+		t.Error("Still throttled after a token refilled")
+	}
+}
+
+func TestThrottleDropsIdleObjects(t *testing.T) {
+	throttle := newAlertThrottle(3)
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		throttle.allow(Event{Namespace: "default", Kind: "Pod", Name: fmt.Sprintf("web-%d", i)}, now)
+	}
+	throttle.allow(Event{Namespace: "default", Kind: "Pod", Name: "db"}, now.Add(throttleIdle))
+
+	if n := len(throttle.limiters); n != 1 {
+		t.Errorf("Kept %d buckets, want only the active one", n)
+	}
+}
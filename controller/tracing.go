@@ -0,0 +1,39 @@
+package controller
+
+// OpenTelemetry tracing of processed items.
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "k8s-controller"
+
+// Returns the controller's tracer. Until setupTracing installs an exporter this
+// is the global no-op tracer.
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// SetupTracing exports spans to the OTLP gRPC collector at endpoint. An empty endpoint leaves
+// the no-op tracer in place. The returned function flushes and stops the exporter.
+func SetupTracing(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlp.NewExporter(ctx, otlpgrpc.NewDriver(
+		otlpgrpc.WithEndpoint(endpoint),
+		otlpgrpc.WithInsecure(),
+	))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
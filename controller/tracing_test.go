@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpanPerProcessedItem(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer otel.SetTracerProvider(previous)
+	tc := newTestController(t, Config{})
+	tc.add("Pod", newPod("web-1"))
+	tc.add("Pod", newPod("web-2"))
+	tc.drain()
+
+	var items int
+	for _, span := range exporter.GetSpans() {
+		if span.Name != "processItem" {
+			continue
+		}
+		items++
+		attributes := map[string]string{}
+		for _, kv := range span.Attributes {
+			attributes[string(kv.Key)] = kv.Value.AsString()
+		}
+		if attributes["resourceType"] != "Pod" || attributes["eventType"] != "create" || attributes["status"] != "Normal" {
+			t.Errorf("Got span attributes %v, want a Normal Pod create", attributes)
+		}
+		if key := attributes["key"]; key != "default/web-1" && key != "default/web-2" {
+			t.Errorf("Got span for key %q", key)
+		}
+		if span.ChildSpanCount != 1 {
+			t.Errorf("Got %d child spans, want the Handle span", span.ChildSpanCount)
+		}
+	}
+	if items != 2 {
+		t.Errorf("Got %d processItem spans, want one per item", items)
+	}
+}
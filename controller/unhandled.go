@@ -0,0 +1,31 @@
+package controller
+
+// Reporting of watched objects which getObjectMetaData does not understand.
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Minimum time between warnings for the same unhandled type.
+const unhandledWarnInterval = time.Minute
+
+var unhandledWarned = struct {
+	sync.Mutex
+	last map[string]time.Time
+}{last: map[string]time.Time{}}
+
+// Counts an object of an unhandled type and warns, at most once per interval per type.
+func reportUnhandledObject(objType string) {
+	unhandledObjectTotal.WithLabelValues(objType).Inc()
+
+	unhandledWarned.Lock()
+	defer unhandledWarned.Unlock()
+	if time.Since(unhandledWarned.last[objType]) < unhandledWarnInterval {
+		return
+	}
+	unhandledWarned.last[objType] = time.Now()
+	log.WithField("type", objType).Warn("Cannot read metadata of unhandled object type, is the controller configured for this resource?")
+}
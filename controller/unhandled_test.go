@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus/hooks/test"
+	storage_v1 "k8s.io/api/storage/v1"
+)
+
+func TestUnhandledObjectCounted(t *testing.T) {
+	logs := test.NewGlobal()
+	counter := unhandledObjectTotal.WithLabelValues("*v1.StorageClass")
+	before := testutil.ToFloat64(counter)
+
+	getObjectMetaData(&storage_v1.StorageClass{})
+	getObjectMetaData(&storage_v1.StorageClass{})
+
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Errorf("Counted %v unhandled objects, want 2", got)
+	}
+	var warnings int
+	for _, entry := range logs.AllEntries() {
+		if entry.Data["type"] == "*v1.StorageClass" {
+			warnings++
+		}
+	}
+	if warnings > 1 {
+		t.Errorf("Warned %d times, want at most once per interval", warnings)
+	}
+}
@@ -0,0 +1,89 @@
+package controller
+
+// Detection of repeated watch failures.
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ListerWatcher which counts consecutive watch failures. The informer's reflector
+// retries a failed watch on its own, but if the apiserver keeps refusing the watch
+// the informer silently stops delivering events. After maxFailures consecutive
+// failures, if set, the controller is aborted so the process exits and can be
+// restarted. Each retry backs off, so a short outage of the apiserver already
+// costs a handful of failures; the limit should be well above that.
+// Permission errors on list or watch abort it straight away, since retrying
+// can't fix them.
+type watchErrorListWatch struct {
+	cache.ListerWatcher
+	resourceType string
+	maxFailures  int
+	controller   *Controller
+
+	mu       sync.Mutex
+	failures int
+}
+
+// WatchErrorHandler wraps lw so the controller notices when watches for resourceType keep failing.
+// The client-go in use predates SharedInformer.SetWatchErrorHandler, so the ListerWatcher is wrapped instead.
+func (c *Controller) WatchErrorHandler(resourceType string, lw cache.ListerWatcher) cache.ListerWatcher {
+	return &watchErrorListWatch{
+		ListerWatcher: lw,
+		resourceType:  resourceType,
+		maxFailures:   c.config.MaxWatchErrors,
+		controller:    c,
+	}
+}
+
+// List lists the objects, aborting the controller on permission errors. Other
+// errors are left to the informer to retry.
+func (w *watchErrorListWatch) List(options meta_v1.ListOptions) (runtime.Object, error) {
+	list, err := w.ListerWatcher.List(options)
+	if err != nil {
+		w.checkFatal("List", err)
+	}
+	return list, err
+}
+
+// Watch starts a watch, tracking consecutive failures.
+func (w *watchErrorListWatch) Watch(options meta_v1.ListOptions) (watch.Interface, error) {
+	wi, err := w.ListerWatcher.Watch(options)
+	if err != nil && w.checkFatal("Watch", err) {
+		return nil, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err == nil {
+		w.failures = 0
+		return wi, nil
+	}
+	w.failures++
+	watchErrorsTotal.WithLabelValues(w.resourceType).Inc()
+	log.WithFields(log.Fields{
+		"kind":     w.resourceType,
+		"failures": w.failures,
+	}).WithError(err).Warn("Watch failed")
+	if w.maxFailures > 0 && w.failures >= w.maxFailures {
+		w.controller.abort(fmt.Errorf("Watch for %s failed %d times in a row: %v", w.resourceType, w.failures, err))
+	}
+	return nil, err
+}
+
+// Aborts the controller if err means it is not allowed to read the resource,
+// reporting whether it did.
+func (w *watchErrorListWatch) checkFatal(verb string, err error) bool {
+	if !apierrors.IsForbidden(err) && !apierrors.IsUnauthorized(err) {
+		return false
+	}
+	watchErrorsTotal.WithLabelValues(w.resourceType).Inc()
+	w.controller.abort(fmt.Errorf("%s of %s not permitted: %v", verb, w.resourceType, err))
+	return true
+}
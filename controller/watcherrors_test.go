@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ListerWatcher whose watches always fail.
+func failingWatch() cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return nil, errors.New("connection refused")
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+}
+
+func (tc *testController) aborted() bool {
+	select {
+	case <-tc.abortCh:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestWatchErrorsCounted(t *testing.T) {
+	tc := newTestController(t, Config{})
+	lw := tc.WatchErrorHandler("Widget", failingWatch())
+	counter := watchErrorsTotal.WithLabelValues("Widget")
+	before := testutil.ToFloat64(counter)
+
+	for i := 0; i < 50; i++ {
+		lw.Watch(meta_v1.ListOptions{})
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 50 {
+		t.Errorf("Counted %v watch errors, want 50", got)
+	}
+	if tc.aborted() {
+		t.Error("Controller stopped without a limit")
+	}
+}
+
+func TestWatchErrorsAbortAtLimit(t *testing.T) {
+	tc := newTestController(t, Config{MaxWatchErrors: 3})
+	lw := tc.WatchErrorHandler("Widget", failingWatch())
+
+	lw.Watch(meta_v1.ListOptions{})
+	lw.Watch(meta_v1.ListOptions{})
+	if tc.aborted() {
+		t.Fatal("Controller stopped before the limit")
+	}
+	lw.Watch(meta_v1.ListOptions{})
+	if !tc.aborted() {
+		t.Error("Controller not stopped at the limit")
+	}
+}
+
+func TestForbiddenIsFatal(t *testing.T) {
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("RBAC denied"))
+	for _, verb := range []string{"List", "Watch"} {
+		tc := newTestController(t, Config{})
+		lw := tc.WatchErrorHandler("Pod", &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return nil, forbidden
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return nil, forbidden
+			},
+		})
+
+		if verb == "List" {
+			lw.List(meta_v1.ListOptions{})
+		} else {
+			lw.Watch(meta_v1.ListOptions{})
+		}
+		if !tc.aborted() {
+			t.Errorf("Controller not stopped by a forbidden %s", verb)
+		}
+	}
+}
+
+func TestTransientListErrorNotFatal(t *testing.T) {
+	tc := newTestController(t, Config{})
+	tc.WatchErrorHandler("Pod", failingWatch()).List(meta_v1.ListOptions{})
+	if tc.aborted() {
+		t.Error("Controller stopped by a transient list error")
+	}
+}
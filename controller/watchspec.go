@@ -0,0 +1,101 @@
+package controller
+
+// Per resource watch scopes and severity overrides.
+
+import (
+	"fmt"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// WatchSpec scopes the watch of one resource type. Informers are built from it,
+// so it only takes effect on restart.
+type WatchSpec struct {
+	// Resource is the resource type, e.g. Pod, as passed to AddInformer.
+	Resource string
+	// Namespace overrides Config.Namespace for this resource.
+	Namespace string
+	// LabelSelector, e.g. "app=web,tier!=db", limits the objects watched.
+	LabelSelector string
+}
+
+// Checks the selector parses.
+func (s WatchSpec) validate() error {
+	if _, err := labels.Parse(s.LabelSelector); err != nil {
+		return fmt.Errorf("Invalid label selector for %s: %v", s.Resource, err)
+	}
+	return nil
+}
+
+// AllResources is the Resource of a WatchSpec, or the key of SeverityRules,
+// applying to every resource type, e.g. to make all deletes Warning. A
+// resource's own spec or rules take precedence.
+const AllResources = "*"
+
+// WatchSpec returns the spec for resourceType, merged over the AllResources
+// spec and falling back to the global namespace and no selector.
+func (config Config) WatchSpec(resourceType string) WatchSpec {
+	spec := WatchSpec{Resource: resourceType}
+	var defaults WatchSpec
+	for _, s := range config.Watches {
+		switch s.Resource {
+		case resourceType:
+			spec = s
+		case AllResources:
+			defaults = s
+		}
+	}
+	if spec.Namespace == "" {
+		spec.Namespace = defaults.Namespace
+	}
+	if spec.Namespace == "" {
+		spec.Namespace = config.Namespace
+	}
+	if spec.LabelSelector == "" {
+		spec.LabelSelector = defaults.LabelSelector
+	}
+	return spec
+}
+
+// Reports whether an object is within the spec's namespace and selector. The
+// informer is scoped the same way already; this catches the rest, e.g. the
+// last state of deleted objects.
+func (s WatchSpec) matches(objectMeta meta_v1.ObjectMeta) bool {
+	if s.Namespace != "" && objectMeta.Namespace != "" && objectMeta.Namespace != s.Namespace {
+		return false
+	}
+	selector, err := labels.Parse(s.LabelSelector)
+	if err != nil {
+		return true
+	}
+	return selector.Matches(labels.Set(objectMeta.Labels))
+}
+
+// Checks every rule maps to a known severity.
+func validSeverityRules(rules map[string]map[string]string) error {
+	for resource, resourceRules := range rules {
+		for match, status := range resourceRules {
+			if err := validSeverity(status); err != nil {
+				return fmt.Errorf("Invalid severity rule %s for %s: %v", match, resource, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the status of kbEvent after applying the severity rules of its
+// resource type, then those for AllResources. Within each, a rule for the
+// reason takes precedence over one for the event type.
+func (config Config) severity(resourceType, eventType string, kbEvent Event) string {
+	for _, resource := range []string{resourceType, AllResources} {
+		rules := config.SeverityRules[resource]
+		if status, ok := rules[kbEvent.Reason]; ok {
+			return status
+		}
+		if status, ok := rules[eventType]; ok {
+			return status
+		}
+	}
+	return kbEvent.Status
+}
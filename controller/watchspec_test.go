@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestWatchSpecsFilterIndependently(t *testing.T) {
+	tc := newTestController(t, Config{Watches: []WatchSpec{
+		{Resource: "Pod", Namespace: "prod"},
+		{Resource: "Deployment", LabelSelector: "app=web"},
+	}})
+	devPod, prodPod := newPod("dev-1"), newPod("prod-1")
+	prodPod.Namespace = "prod"
+	other, web := newDeployment("other"), newDeployment("web")
+	web.Labels = map[string]string{"app": "web"}
+	for _, pod := range []interface{}{devPod, prodPod} {
+		tc.add("Pod", pod)
+	}
+	for _, deployment := range []interface{}{other, web} {
+		tc.add("Deployment", deployment)
+	}
+	tc.drain()
+
+	got := map[string]bool{}
+	for _, e := range tc.handler.Events() {
+		got[e.Kind+"/"+e.Namespace+"/"+e.Name] = true
+	}
+	want := map[string]bool{"Pod/prod/prod-1": true, "Deployment/default/web": true}
+	if len(got) != len(want) {
+		t.Fatalf("Got events for %v, want %v", got, want)
+	}
+	for key := range want {
+		if !got[key] {
+			t.Errorf("Got events for %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSeverityRulesPrecedence(t *testing.T) {
+	config := Config{SeverityRules: map[string]map[string]string{
+		AllResources: {"delete": "Warning", "Restarted": "Warning"},
+		"Pod":        {"delete": "Normal"},
+	}}
+	tests := []struct {
+		resourceType, eventType, reason string
+		want                            string
+	}{
+		{"Pod", "delete", "Deleted", "Normal"},
+		{"Deployment", "delete", "Deleted", "Warning"},
+		{"Pod", "update", "Restarted", "Warning"},
+		{"Pod", "create", "Created", "Normal"},
+	}
+	for _, test := range tests {
+		got := config.severity(test.resourceType, test.eventType, Event{Reason: test.reason, Status: "Normal"})
+		if got != test.want {
+			t.Errorf("severity(%s, %s, %s) = %s, want %s", test.resourceType, test.eventType, test.reason, got, test.want)
+		}
+	}
+}
+
+func TestReloadSeverityRules(t *testing.T) {
+	tc := newTestController(t, Config{})
+	tc.add("Pod", newPod("web-1"))
+	tc.drain()
+
+	if err := tc.Reload(Config{SeverityRules: map[string]map[string]string{"Pod": {"Created": "Warning"}}}); err != nil {
+		t.Fatal(err)
+	}
+	tc.add("Pod", newPod("web-2"))
+	tc.drain()
+
+	events := tc.handler.Events()
+	if len(events) != 2 || events[0].Status != "Normal" || events[1].Status != "Warning" {
+		t.Fatalf("Got %+v, want web-1 Normal then web-2 Warning", events)
+	}
+}
+
+func TestInvalidSeverityRule(t *testing.T) {
+	config := Config{SeverityRules: map[string]map[string]string{"Pod": {"delete": "Urgent"}}}
+	if err := config.Validate(); err == nil {
+		t.Error("Got no error for severity Urgent")
+	}
+}
+
+func TestWatchSpecNamespace(t *testing.T) {
+	config := Config{Namespace: "default", Watches: []WatchSpec{
+		{Resource: AllResources, Namespace: "staging"},
+		{Resource: "Pod", Namespace: "prod", LabelSelector: "app=web"},
+		{Resource: "Deployment", LabelSelector: "app=web"},
+	}}
+	tests := []struct {
+		resource, namespace string
+	}{
+		{"Pod", "prod"},
+		{"Deployment", "staging"},
+		{"Event", "staging"},
+	}
+	for _, test := range tests {
+		if got := config.WatchSpec(test.resource).Namespace; got != test.namespace {
+			t.Errorf("%s watches namespace %q, want %q", test.resource, got, test.namespace)
+		}
+	}
+	if got := (Config{Namespace: "default"}).WatchSpec("Pod").Namespace; got != "default" {
+		t.Errorf("Pod watches namespace %q, want the global default", got)
+	}
+	if got := (Config{}).WatchSpec("Pod").Namespace; got != meta_v1.NamespaceAll {
+		t.Errorf("Pod watches namespace %q, want all", got)
+	}
+}
+
+func TestNamespacedListWatch(t *testing.T) {
+	prodPod := newPod("web-1")
+	prodPod.Namespace = "prod"
+	client := fake.NewSimpleClientset(newPod("web-0"), prodPod)
+	spec := Config{Namespace: "prod"}.WatchSpec("Pod")
+	informer := NewInformer(&cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return client.CoreV1().Pods(spec.Namespace).List(context.TODO(), options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return client.CoreV1().Pods(spec.Namespace).Watch(context.TODO(), options)
+		},
+	}, &api_v1.Pod{}, Config{})
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go informer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		t.Fatal("Cache did not sync")
+	}
+
+	if keys := informer.GetStore().ListKeys(); len(keys) != 1 || keys[0] != "prod/web-1" {
+		t.Errorf("Got %v, want only prod/web-1", keys)
+	}
+}
+
+func TestDeleteSeverityOverride(t *testing.T) {
+	tc := newTestController(t, Config{SeverityRules: map[string]map[string]string{"Pod": {"delete": "Normal"}}})
+	pod, deployment := newPod("web-1"), newDeployment("web")
+	tc.add("Pod", pod)
+	tc.add("Deployment", deployment)
+	tc.drain()
+	tc.remove("Pod", pod)
+	tc.remove("Deployment", deployment)
+	tc.drain()
+
+	statuses := map[string]string{}
+	for _, e := range tc.handler.Events() {
+		if e.Reason == "Deleted" {
+			statuses[e.Kind] = e.Status
+		}
+	}
+	if statuses["Pod"] != "Normal" || statuses["Deployment"] != "Danger" {
+		t.Errorf("Got delete statuses %v, want Pod Normal and Deployment Danger", statuses)
+	}
+}
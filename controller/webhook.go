@@ -0,0 +1,81 @@
+package controller
+
+// Handler which POSTs events as JSON to an arbitrary webhook.
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// TLSOptions configures how an HTTP handler verifies and authenticates to its server.
+type TLSOptions struct {
+	// CAFile is a PEM bundle of CAs trusted in addition to the system roots.
+	CAFile string
+	// CertFile and KeyFile hold a client certificate for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables server certificate verification. Never use it in production.
+	InsecureSkipVerify bool
+}
+
+// Builds a tls.Config from the options.
+func (o TLSOptions) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+	if o.CAFile != "" {
+		pem, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading CA bundle: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No certificates found in CA bundle %s", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// WebhookHandler POSTs each event as JSON.
+type WebhookHandler struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookHandler returns a handler posting to url over a client using
+// tlsOptions, which fails requests taking longer than timeout.
+func NewWebhookHandler(url string, tlsOptions TLSOptions, timeout time.Duration) (*WebhookHandler, error) {
+	tlsConfig, err := tlsOptions.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &WebhookHandler{
+		URL:    url,
+		Client: &http.Client{Transport: transport, Timeout: timeout},
+	}, nil
+}
+
+// Handle posts the event, with its ID as the Idempotency-Key header.
+func (w *WebhookHandler) Handle(e Event) error {
+	return postJSONWithHeaders(w.Client, w.URL, e, map[string]string{"Idempotency-Key": e.ID})
+}
+
+// HandleBatch posts the events as one JSON array. Each carries its own ID.
+func (w *WebhookHandler) HandleBatch(events []Event) error {
+	return postJSON(w.Client, w.URL, events)
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Starts a TLS server recording the event posted to it, and writes its
+// certificate to a CA bundle. Returns the server and the bundle's path.
+func tlsWebhook(t *testing.T, got *Event) (*httptest.Server, string) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(got)
+	}))
+	t.Cleanup(server.Close)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, ca, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return server, caFile
+}
+
+func TestWebhookTrustsCA(t *testing.T) {
+	var got Event
+	server, caFile := tlsWebhook(t, &got)
+	h, err := NewWebhookHandler(server.URL, TLSOptions{CAFile: caFile}, DefaultHTTPTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Handle(Event{ID: "abc", Kind: "Pod", Name: "web-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != "abc" || got.Name != "web-1" {
+		t.Errorf("Server got %+v, want the posted event", got)
+	}
+}
+
+func TestWebhookRejectsUnknownCA(t *testing.T) {
+	var got Event
+	server, _ := tlsWebhook(t, &got)
+	h, err := NewWebhookHandler(server.URL, TLSOptions{}, DefaultHTTPTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Handle(Event{ID: "abc"}); err == nil {
+		t.Error("Got no error posting to a server with an untrusted certificate")
+	}
+}
+
+func TestWebhookInvalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(caFile, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewWebhookHandler("https://example.com", TLSOptions{CAFile: caFile}, DefaultHTTPTimeout); err == nil {
+		t.Error("Got no error for a CA bundle without certificates")
+	}
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	h, err := NewWebhookHandler(server.URL, TLSOptions{}, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := h.Handle(Event{ID: "abc"}); err == nil {
+		t.Fatal("Got no error from a webhook which never answers")
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("Handle took %s, want it to give up after the 50ms timeout", took)
+	}
+}
@@ -0,0 +1,45 @@
+// Package version holds build information, set at link time with e.g.
+//
+//	go build -ldflags "-X ohthehugemanatee/k8s-controller-demo/version.Version=v1.2.3 \
+//	  -X ohthehugemanatee/k8s-controller-demo/version.Commit=$(git rev-parse HEAD) \
+//	  -X ohthehugemanatee/k8s-controller-demo/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Build information, overridden with -ldflags -X.
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+}
+
+// Get returns the build information.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+	}
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, built %s)", i.Version, i.Commit, i.BuildDate)
+}
+
+// ServeHTTP serves the build information as JSON.
+func ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Get())
+}
@@ -0,0 +1,30 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServeHTTP(t *testing.T) {
+	defer func(version, commit, buildDate string) {
+		Version, Commit, BuildDate = version, commit, buildDate
+	}(Version, Commit, BuildDate)
+	Version, Commit, BuildDate = "v1.2.3", "abc123", "2026-01-02T03:04:05Z"
+
+	recorder := httptest.NewRecorder()
+	ServeHTTP(recorder, httptest.NewRequest("GET", "/version", nil))
+
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Got Content-Type %q, want application/json", ct)
+	}
+	var fields map[string]string
+	if err := json.NewDecoder(recorder.Body).Decode(&fields); err != nil {
+		t.Fatal(err)
+	}
+	for field, want := range map[string]string{"version": "v1.2.3", "commit": "abc123", "buildDate": "2026-01-02T03:04:05Z"} {
+		if fields[field] != want {
+			t.Errorf("Got %s %q, want %q", field, fields[field], want)
+		}
+	}
+}